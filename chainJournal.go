@@ -0,0 +1,260 @@
+package devscripts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrJournalMismatch is returned (wrapped) when a journal file belongs to another chain
+// definition and cannot be used to resume
+var ErrJournalMismatch = errors.New("journal does not match the chain definition")
+
+// chainJournal is the persisted state of a chain run
+type chainJournal struct {
+	path  string
+	Chain string                  `json:"chain"`
+	Hash  string                  `json:"hash"`
+	Steps map[int]journalStepInfo `json:"steps"`
+}
+
+// journalStepInfo records the outcome of a step that ran
+type journalStepInfo struct {
+	Scripts   []string          `json:"scripts"`
+	ExitCode  int               `json:"exit_code"`
+	Completed bool              `json:"completed"`
+	Error     string            `json:"error,omitempty"`
+	Outputs   map[string]string `json:"outputs,omitempty"`
+	End       time.Time         `json:"end"`
+}
+
+// Named sets the name of the chain, used to key its journal
+func (sc *ScriptChain) Named(name string) *ScriptChain {
+	sc.name = name
+	return sc
+}
+
+// Journal makes the chain record each step it runs in a journal file in dir, so that an
+// interrupted run can be continued with Resume. The file is named after the chain name and
+// a hash of its definition, and it is removed once the chain succeeds.
+func (sc *ScriptChain) Journal(dir string) *ScriptChain {
+	sc.journalDir = dir
+	return sc
+}
+
+// JournalPath returns the path of the journal file of the chain, empty without a journal
+func (sc *ScriptChain) JournalPath() string {
+	if sc.journalDir == "" {
+		return ""
+	}
+	return sc.journalFile(sc.hash())
+}
+
+// journalFile returns the path of the journal file for the hash of the chain definition
+func (sc *ScriptChain) journalFile(hash string) string {
+	name := sc.name
+	if name == "" {
+		name = "chain"
+	}
+	return filepath.Join(sc.journalDir, fmt.Sprintf("%s-%s.json", name, hash[:12]))
+}
+
+// Resume runs the chain, skipping the steps its journal records as completed, so it continues
+// from the step that failed. Without a journal file it runs the whole chain.
+func (sc *ScriptChain) Resume() (int, string, error) {
+	return sc.ResumeContext(context.Background())
+}
+
+// ResumeContext is like Resume but stops when ctx is done
+func (sc *ScriptChain) ResumeContext(ctx context.Context) (int, string, error) {
+	if sc.journalDir == "" {
+		return 1, "", errors.New("resume requires a journal, see ScriptChain.Journal")
+	}
+	if sc.isDryRun() {
+		return summarize(sc.dryRun(ctx))
+	}
+
+	journal, err := sc.loadJournal()
+	if err != nil {
+		return summarize(sc.failBeforeRun(0, err))
+	}
+	return summarize(sc.run(ctx, journal))
+}
+
+// hashedRetry is the part of a RetryPolicy that is hashed, without the predicate
+type hashedRetry struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Jitter      float64
+}
+
+// hashedCommand is the part of a Command that is hashed, without the Stdin reader
+type hashedCommand struct {
+	Script     string
+	Args       []string
+	Dir        string
+	Env        []string
+	EnvAllow   []string
+	IsolateEnv bool
+	Input      string
+	Retry      *hashedRetry
+}
+
+// hash returns a hash of the chain definition. Readers passed as Stdin and retry
+// predicates are not part of it.
+func (sc *ScriptChain) hash() string {
+	type hashedStep struct {
+		Commands     []hashedCommand
+		Parallel     bool
+		Workers      int
+		Policy       GroupPolicy
+		Kind         stepKind
+		AllowFailure bool
+		Outputs      []string
+	}
+
+	steps := make([]hashedStep, 0, len(sc.steps))
+	for _, step := range sc.steps {
+		commands := make([]hashedCommand, len(step.commands))
+		for i, command := range step.commands {
+			commands[i] = hashedCommand{
+				Script:     command.Script,
+				Args:       command.Args,
+				Dir:        command.Dir,
+				Env:        command.Env,
+				EnvAllow:   command.EnvAllow,
+				IsolateEnv: command.IsolateEnv,
+				Input:      command.Input,
+			}
+			if retry := command.Retry; retry != nil {
+				commands[i].Retry = &hashedRetry{retry.MaxAttempts, retry.Backoff, retry.MaxBackoff, retry.Jitter}
+			}
+		}
+		steps = append(steps, hashedStep{commands, step.parallel, step.workers, step.policy, step.kind, step.allowFailure, step.outputs})
+	}
+
+	// Only strings, numbers and booleans are hashed, so marshalling cannot fail
+	data, _ := json.Marshal(struct {
+		Name  string
+		Steps []hashedStep
+	}{sc.name, steps})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newJournal starts an empty journal, checking that it can be written
+func (sc *ScriptChain) newJournal() (*chainJournal, error) {
+	hash := sc.hash()
+	journal := &chainJournal{
+		path:  sc.journalFile(hash),
+		Chain: sc.name,
+		Hash:  hash,
+		Steps: map[int]journalStepInfo{},
+	}
+	if err := os.MkdirAll(sc.journalDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating journal directory: %w", err)
+	}
+	if err := journal.save(); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// loadJournal reads the journal of the chain, or starts a new one if there is none
+func (sc *ScriptChain) loadJournal() (*chainJournal, error) {
+	hash := sc.hash()
+	path := sc.journalFile(hash)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sc.newJournal()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	journal := &chainJournal{}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("error parsing journal %s: %w", path, err)
+	}
+	if journal.Chain != sc.name || journal.Hash != hash {
+		return nil, fmt.Errorf("%w: %s", ErrJournalMismatch, path)
+	}
+
+	journal.path = path
+	if journal.Steps == nil {
+		journal.Steps = map[int]journalStepInfo{}
+	}
+	return journal, nil
+}
+
+// completed reports whether the step at index ran successfully in a previous run
+func (j *chainJournal) completed(index int) bool {
+	return j != nil && j.Steps[index].Completed
+}
+
+// restoreOutputs adds the outputs recorded for the step to outputs
+func (j *chainJournal) restoreOutputs(index int, outputs map[string]string) {
+	for name, value := range j.Steps[index].Outputs {
+		outputs[name] = value
+	}
+}
+
+// record stores the outcome of the step and saves the journal
+func (j *chainJournal) record(index int, results []*ExecResult) {
+	if j == nil {
+		return
+	}
+
+	info := journalStepInfo{Completed: firstFailure(results) == nil, Outputs: map[string]string{}, End: time.Now()}
+	var errs []string
+	for _, result := range results {
+		info.Scripts = append(info.Scripts, result.Script)
+		if result.ExitCode != 0 {
+			info.ExitCode = result.ExitCode
+		}
+		if result.Err != nil {
+			errs = append(errs, result.Err.Error())
+		}
+		for name, value := range result.Outputs {
+			info.Outputs[name] = value
+		}
+	}
+	info.Error = strings.Join(errs, "; ")
+
+	j.Steps[index] = info
+	// Recording is best effort, the journal file was already written once when the run started
+	j.save()
+}
+
+// save writes the journal atomically
+func (j *chainJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding journal: %w", err)
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the journal file once the chain succeeded
+func (j *chainJournal) remove() {
+	if j != nil {
+		os.Remove(j.path)
+	}
+}
@@ -0,0 +1,156 @@
+package devscripts
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScriptChainResume(t *testing.T) {
+	tempDir := t.TempDir()
+	journalDir := filepath.Join(t.TempDir(), "journals")
+
+	writeScripts(t, tempDir, map[string]string{
+		// Counts its runs so we can check it is not repeated on resume
+		"version.sh": "#!/bin/bash\necho run >> runs.txt\necho \"::output::tag=v1.0.0\"\n",
+		// Fails until the network is back
+		"push.sh":    "#!/bin/bash\nif [[ ! -f network.ok ]]; then echo \"network down\"; exit 128; fi\necho \"pushed $1\"\n",
+		"cleanup.sh": "#!/bin/bash\necho \"cleanup\"\n",
+	})
+
+	newChain := func() *ScriptChain {
+		return NewScriptRunner(tempDir).Chain().
+			Named("release").
+			Journal(journalDir).
+			Then("version.sh").Outputs("tag").
+			Then("push.sh", "{{tag}}").
+			Finally("cleanup.sh")
+	}
+
+	exitCode, _, err := newChain().Execute()
+	if exitCode != 128 || err == nil {
+		t.Fatalf("Expected push to fail, got %d %v", exitCode, err)
+	}
+
+	chain := newChain()
+	if !strings.HasPrefix(filepath.Base(chain.JournalPath()), "release-") {
+		t.Errorf("Expected journal named after the chain, got %s", chain.JournalPath())
+	}
+	if _, err := os.Stat(chain.JournalPath()); err != nil {
+		t.Fatalf("Expected journal file after the failed run: %v", err)
+	}
+
+	// The network is back: resume continues from the failed step
+	if err := os.WriteFile(filepath.Join(tempDir, "network.ok"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write network.ok: %v", err)
+	}
+
+	exitCode, output, err := chain.Resume()
+	if exitCode != 0 || err != nil {
+		t.Fatalf("Expected resume to succeed, got %d %v\n%s", exitCode, err, output)
+	}
+	if !strings.Contains(output, "pushed v1.0.0") || !strings.Contains(output, "cleanup") {
+		t.Errorf("Expected push with the restored output and cleanup, got %q", output)
+	}
+	if results := chain.Results(); results[0].SkipReason != "completed in a previous run" {
+		t.Errorf("Expected version.sh to be skipped as completed, got %q", results[0].SkipReason)
+	}
+	if runs := readFile(t, filepath.Join(tempDir, "runs.txt")); strings.Count(runs, "run") != 1 {
+		t.Errorf("Expected version.sh to run once, ran %d times", strings.Count(runs, "run"))
+	}
+	if _, err := os.Stat(chain.JournalPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected journal to be removed after success, got %v", err)
+	}
+}
+
+func TestScriptChainJournalKey(t *testing.T) {
+	tempDir := t.TempDir()
+	journalDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"fail.sh": "#!/bin/bash\nexit 1\n",
+	})
+
+	chain := NewScriptRunner(tempDir).Chain().Named("flow").Journal(journalDir).Then("fail.sh", "a")
+	chain.Execute()
+
+	// A changed definition uses another journal
+	changed := NewScriptRunner(tempDir).Chain().Named("flow").Journal(journalDir).Then("fail.sh", "b")
+	if changed.JournalPath() == chain.JournalPath() {
+		t.Error("Expected a changed chain to use a different journal")
+	}
+
+	// A journal whose hash does not match is rejected
+	data, err := os.ReadFile(chain.JournalPath())
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	var journal map[string]any
+	if err := json.Unmarshal(data, &journal); err != nil {
+		t.Fatalf("Failed to parse journal: %v", err)
+	}
+	journal["hash"] = "tampered"
+	data, _ = json.Marshal(journal)
+	if err := os.WriteFile(chain.JournalPath(), data, 0644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	if _, _, err := chain.Resume(); !errors.Is(err, ErrJournalMismatch) {
+		t.Errorf("Expected ErrJournalMismatch, got %v", err)
+	}
+
+	// Resume needs a journal
+	if _, _, err := NewScriptRunner(tempDir).Chain().Then("fail.sh").Resume(); err == nil {
+		t.Error("Expected resume without journal to fail")
+	}
+}
+
+func TestScriptChainJournalRetry(t *testing.T) {
+	tempDir := t.TempDir()
+	journalDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		// Fails the first time it runs
+		"flaky.sh": "#!/bin/bash\nif [[ ! -f tried ]]; then touch tried; exit 75; fi\necho \"done\"\n",
+		"push.sh":  "#!/bin/bash\nif [[ ! -f network.ok ]]; then exit 75; fi\necho \"pushed\"\n",
+	})
+
+	newChain := func(policy RetryPolicy) *ScriptChain {
+		return NewScriptRunner(tempDir).Chain().Journal(journalDir).Then("flaky.sh").Retry(policy)
+	}
+
+	chain := newChain(RetryPolicy{MaxAttempts: 3, RetryIf: RetryOnExitCodes(75)})
+	exitCode, output, err := chain.Execute()
+	if exitCode != 0 || err != nil || !strings.Contains(output, "done") {
+		t.Fatalf("Expected the retried step to succeed, got %d %v\n%s", exitCode, err, output)
+	}
+	if attempts := len(chain.Results()[0].Attempts); attempts != 1 {
+		t.Errorf("Expected one earlier attempt, got %d", attempts)
+	}
+
+	// The retry policy is part of the definition
+	if newChain(RetryPolicy{MaxAttempts: 2}).JournalPath() == chain.JournalPath() {
+		t.Error("Expected a changed retry policy to use a different journal")
+	}
+
+	// A step that runs out of attempts leaves a journal to resume from
+	chain = NewScriptRunner(tempDir).Chain().Journal(journalDir).
+		Then("flaky.sh").
+		Then("push.sh").Retry(RetryPolicy{MaxAttempts: 2})
+	if exitCode, _, err := chain.Execute(); exitCode != 75 || err == nil {
+		t.Fatalf("Expected push to fail after its retries, got %d %v", exitCode, err)
+	}
+	if _, err := os.Stat(chain.JournalPath()); err != nil {
+		t.Fatalf("Expected journal file after the failed run: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "network.ok"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write network.ok: %v", err)
+	}
+	exitCode, output, err = chain.Resume()
+	if exitCode != 0 || err != nil || !strings.Contains(output, "pushed") || strings.Contains(output, "done") {
+		t.Errorf("Expected resume to run only the retried step, got %d %v\n%s", exitCode, err, output)
+	}
+}
@@ -0,0 +1,182 @@
+package devscripts
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// OutputMarker prefixes the stdout lines that set a step output, e.g. "::output::tag=v1.2.3"
+const OutputMarker = "::output::"
+
+// OutputFileEnv names the variable holding the path of a file where a step can write its
+// outputs as "KEY=value" lines, as an alternative to OutputMarker lines
+const OutputFileEnv = "DEVSCRIPTS_OUTPUT"
+
+// ErrMissingOutput is returned (wrapped) when a step references an output that no earlier
+// step declares, or when a step does not set an output it declared
+var ErrMissingOutput = errors.New("missing step output")
+
+// outputRef matches references to step outputs like {{tag}} in arguments and environment
+var outputRef = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Outputs declares the named outputs set by the last added step. Later steps reference
+// them as {{name}} in their arguments or in the values of their environment variables.
+// A single step fails if it does not set a declared output; in a parallel group any
+// command may set it.
+func (sc *ScriptChain) Outputs(names ...string) *ScriptChain {
+	if step := sc.lastStep(); step != nil {
+		step.outputs = append(step.outputs, names...)
+	}
+	return sc
+}
+
+// Validate checks that every output referenced by a step is declared by an earlier step
+func (sc *ScriptChain) Validate() error {
+	_, err := sc.validate()
+	return err
+}
+
+// validate returns the index of the first invalid step along with the error
+func (sc *ScriptChain) validate() (int, error) {
+	declared := map[string]bool{}
+
+	for i, step := range sc.steps {
+		for _, command := range step.commands {
+			for _, ref := range commandRefs(command) {
+				if !declared[ref] {
+					return i, fmt.Errorf("script '%s' references {{%s}}: %w: no earlier step declares it", command.Script, ref, ErrMissingOutput)
+				}
+			}
+		}
+		for _, name := range step.outputs {
+			declared[name] = true
+		}
+	}
+
+	return -1, nil
+}
+
+// commandRefs returns the output names referenced by the command
+func commandRefs(command Command) []string {
+	var refs []string
+	for _, value := range append(append([]string{}, command.Args...), command.Env...) {
+		for _, match := range outputRef.FindAllStringSubmatch(value, -1) {
+			refs = append(refs, match[1])
+		}
+	}
+	return refs
+}
+
+// expandOutputs replaces the output references of the command with their values
+func expandOutputs(command Command, outputs map[string]string) (Command, error) {
+	var missing error
+	expand := func(value string) string {
+		return outputRef.ReplaceAllStringFunc(value, func(ref string) string {
+			name := outputRef.FindStringSubmatch(ref)[1]
+			v, ok := outputs[name]
+			if !ok && missing == nil {
+				missing = fmt.Errorf("script '%s' references {{%s}}: %w: the step setting it did not run", command.Script, name, ErrMissingOutput)
+			}
+			return v
+		})
+	}
+
+	expanded := command
+	expanded.Args = make([]string, len(command.Args))
+	for i, arg := range command.Args {
+		expanded.Args[i] = expand(arg)
+	}
+	expanded.Env = make([]string, len(command.Env))
+	for i, kv := range command.Env {
+		expanded.Env[i] = expand(kv)
+	}
+
+	return expanded, missing
+}
+
+// parseOutputs reads "KEY=value" outputs from the OutputMarker lines of stdout and from the
+// output file. Values from the file win.
+func parseOutputs(stdout, outputFile string) map[string]string {
+	outputs := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, OutputMarker); ok {
+			if key, value, ok := strings.Cut(rest, "="); ok {
+				outputs[strings.TrimSpace(key)] = value
+			}
+		}
+	}
+
+	if content, err := os.ReadFile(outputFile); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) != "" {
+				outputs[strings.TrimSpace(key)] = value
+			}
+		}
+	}
+
+	return outputs
+}
+
+// checkGroupOutputs fails a successful parallel group when none of its commands set one of
+// the declared outputs. The error is reported on the first command of the group that ran.
+func checkGroupOutputs(step *chainStep, results []*ExecResult) {
+	if !step.parallel || len(step.outputs) == 0 || firstFailure(results) != nil {
+		return
+	}
+
+	var missing []string
+	for _, name := range step.outputs {
+		set := slices.ContainsFunc(results, func(result *ExecResult) bool {
+			_, ok := result.Outputs[name]
+			return ok
+		})
+		if !set {
+			missing = append(missing, strconv.Quote(name))
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	for _, result := range results {
+		if result.Success() {
+			result.ExitCode = 1
+			result.Err = fmt.Errorf("no script of the parallel group set output %s: %w", strings.Join(missing, ", "), ErrMissingOutput)
+			return
+		}
+	}
+}
+
+// collectOutputs stores the declared outputs set by the result into the chain outputs.
+// A successful step that does not set a declared output fails.
+func collectOutputs(step *chainStep, result *ExecResult, outputFile string, outputs map[string]string) {
+	if len(step.outputs) == 0 || !result.Success() {
+		return
+	}
+
+	produced := parseOutputs(result.Stdout, outputFile)
+	result.Outputs = map[string]string{}
+
+	for _, name := range step.outputs {
+		value, ok := produced[name]
+		if !ok {
+			if !step.parallel {
+				result.ExitCode = 1
+				result.Err = fmt.Errorf("script '%s' did not set output %q: %w", result.Script, name, ErrMissingOutput)
+			}
+			continue
+		}
+		result.Outputs[name] = value
+		outputs[name] = value
+	}
+}
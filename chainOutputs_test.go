@@ -0,0 +1,102 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScriptChainOutputs(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"version.sh": "#!/bin/bash\necho \"computing version\"\necho \"::output::tag=v1.2.3\"\n",
+		"module.sh":  "#!/bin/bash\necho \"module=github.com/cdvelop/mdgo\" >> \"$DEVSCRIPTS_OUTPUT\"\n",
+		"update.sh":  "#!/bin/bash\necho \"update $1 to $2 with token $TOKEN\"\n",
+		"noout.sh":   "#!/bin/bash\necho \"nothing\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	chain := runner.Chain().
+		Then("version.sh").Outputs("tag").
+		Then("module.sh").Outputs("module").
+		ThenCommand(Command{
+			Script: "update.sh",
+			Args:   []string{"{{module}}", "{{ tag }}"},
+			Env:    []string{"TOKEN=secret-{{tag}}"},
+		})
+
+	results := chain.Run(context.Background())
+	for _, result := range results {
+		if !result.Success() {
+			t.Fatalf("Step %s failed: %v", result.Script, result.Err)
+		}
+	}
+	if results[0].Outputs["tag"] != "v1.2.3" || results[1].Outputs["module"] != "github.com/cdvelop/mdgo" {
+		t.Errorf("Unexpected outputs: %v %v", results[0].Outputs, results[1].Outputs)
+	}
+	if want := "update github.com/cdvelop/mdgo to v1.2.3 with token secret-v1.2.3\n"; results[2].Stdout != want {
+		t.Errorf("Expected %q, got %q", want, results[2].Stdout)
+	}
+
+	// A declared output that is not set fails the step
+	exitCode, _, err := runner.Chain().Then("noout.sh").Outputs("tag").Then("update.sh", "{{tag}}").Execute()
+	if exitCode != 1 || !errors.Is(err, ErrMissingOutput) {
+		t.Errorf("Expected missing output to fail the step, got %d %v", exitCode, err)
+	}
+
+	// A parallel group fails when none of its commands sets a declared output
+	chain = runner.Chain().
+		Parallel(NewCommand("noout.sh"), NewCommand("noout.sh")).Outputs("tag").
+		Then("update.sh", "{{tag}}")
+	results = chain.Run(context.Background())
+	if !errors.Is(results[0].Err, ErrMissingOutput) || !strings.Contains(results[0].Err.Error(), "parallel group") {
+		t.Errorf("Expected the group to fail with ErrMissingOutput, got %v", results[0].Err)
+	}
+	if !results[2].Skipped() || strings.Contains(results[2].SkipReason, "did not run") {
+		t.Errorf("Expected the consumer to be skipped because the group failed, got %q %v", results[2].SkipReason, results[2].Err)
+	}
+
+	// One command of the group setting it is enough
+	_, output, err := runner.Chain().
+		Parallel(NewCommand("noout.sh"), NewCommand("version.sh")).Outputs("tag").
+		Then("update.sh", "{{tag}}").
+		Execute()
+	if err != nil || !strings.Contains(output, "update v1.2.3") {
+		t.Errorf("Expected the output of one group command to be enough, got %q %v", output, err)
+	}
+}
+
+func TestScriptChainOutputsValidation(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"first.sh":   "#!/bin/bash\necho \"first ran\"\n",
+		"version.sh": "#!/bin/bash\necho \"::output::tag=v1\"\n",
+	})
+
+	// The reference comes before the step declaring it
+	chain := NewScriptRunner(tempDir).Chain().
+		Then("first.sh").
+		Then("first.sh", "{{tag}}").
+		Then("version.sh").Outputs("tag").
+		Finally("first.sh")
+
+	if err := chain.Validate(); !errors.Is(err, ErrMissingOutput) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	_, output, err := chain.Execute()
+	if !errors.Is(err, ErrMissingOutput) || !strings.Contains(err.Error(), "{{tag}}") {
+		t.Errorf("Expected missing reference error, got %v", err)
+	}
+	if output != "" {
+		t.Errorf("Expected nothing to run, got %q", output)
+	}
+	results := chain.Results()
+	if len(results) != 4 || !results[0].Skipped() || results[1].Skipped() || !results[3].Skipped() {
+		t.Errorf("Expected the invalid step to fail and the others to be skipped, got %d results", len(results))
+	}
+}
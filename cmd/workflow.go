@@ -0,0 +1,11 @@
+//go:build ignore
+
+package main
+
+import (
+	"github.com/cdvelop/devscripts"
+)
+
+func main() {
+	devscripts.ExecuteWithArgs(devscripts.RunWorkflow)
+}
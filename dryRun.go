@@ -0,0 +1,267 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithDryRun makes the runner resolve every execution without running anything. Results
+// report the resolved command line as output, or the reason the script cannot run.
+func WithDryRun() RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.dryRun = true
+	}
+}
+
+// PlanStep describes what an execution would do, without running it
+type PlanStep struct {
+	Step         string   // Position in the chain, e.g. "2" or "3.1" inside a parallel group
+	When         string   // "success", "failure" or "always"
+	AllowFailure bool     // The step may fail without stopping the chain
+	Script       string   // Script name as requested
+	Path         string   // Resolved path of the script file
+	Interpreter  string   // Interpreter command that would run the script
+	Argv         []string // Final command line, starting with the interpreter
+	Dir          string   // Working directory of the process
+	EnvSet       []string // "KEY=value" variables added or changed compared to the current process
+	EnvUnset     []string // Names of variables of the current process that would not be inherited
+	Err          error    // Why the script cannot run, e.g. missing file or unsupported type
+}
+
+// Plan is the list of executions a runner or chain would perform
+type Plan []PlanStep
+
+// PlanCommand resolves the command like Run would, without running it
+func (sr *ScriptRunner) PlanCommand(command Command) PlanStep {
+	step := PlanStep{
+		When:   "success",
+		Script: command.Script,
+		Dir:    sr.workDirFor(command),
+	}
+	step.EnvSet, step.EnvUnset = envDiff(os.Environ(), sr.buildEnv(command))
+
+	scriptPath, interpreter, err := sr.resolve(command.Script)
+	step.Path = scriptPath
+	if err != nil {
+		step.Err = err
+		return step
+	}
+	step.Interpreter = interpreter.Command
+
+	program, args := sr.commandLine(interpreter, scriptPath, command.Args)
+	step.Argv = append([]string{program}, args...)
+
+	return step
+}
+
+// Plan resolves every step of the chain without running anything. Conditions are not
+// evaluated, so OnFailure and Finally steps are listed too.
+func (sc *ScriptChain) Plan() Plan {
+	invalid, validationErr := sc.validate()
+
+	var plan Plan
+	for i, step := range sc.steps {
+		for j, command := range step.commands {
+			planned := PlanStep{Script: command.Script, Dir: command.Dir}
+			if p, ok := sc.runner.(planner); ok {
+				planned = p.PlanCommand(command)
+			}
+
+			planned.Step = strconv.Itoa(i + 1)
+			if step.parallel {
+				planned.Step += "." + strconv.Itoa(j+1)
+			}
+			switch step.kind {
+			case stepOnFailure:
+				planned.When = "failure"
+			case stepFinally:
+				planned.When = "always"
+			}
+			planned.AllowFailure = step.allowFailure
+
+			if i == invalid && j == 0 && planned.Err == nil {
+				planned.Err = validationErr
+			}
+			plan = append(plan, planned)
+		}
+	}
+	return plan
+}
+
+// planner is implemented by runners that resolve commands without running them
+type planner interface {
+	PlanCommand(command Command) PlanStep
+	isDryRun() bool
+}
+
+// isDryRun reports whether the chain is run by a dry-run runner
+func (sc *ScriptChain) isDryRun() bool {
+	p, ok := sc.runner.(planner)
+	return ok && p.isDryRun()
+}
+
+// dryRun returns the results of a chain run by a dry-run runner. Every command goes through
+// the runner, so hooks and redaction apply as they do to single commands. Conditions are
+// not evaluated, so OnFailure and Finally steps are listed too.
+func (sc *ScriptChain) dryRun(ctx context.Context) []*ExecResult {
+	invalid, validationErr := sc.validate()
+
+	results := make([]*ExecResult, 0, len(sc.steps))
+	for i, step := range sc.steps {
+		for j, command := range step.commands {
+			if i == invalid && j == 0 {
+				result := notStarted(command)
+				result.DryRun = true
+				results = append(results, result.fail(validationErr))
+				continue
+			}
+			results = append(results, sc.runner.Run(ctx, command))
+		}
+	}
+	return sc.setResults(results)
+}
+
+// isDryRun reports whether the runner was created with WithDryRun
+func (sr *ScriptRunner) isDryRun() bool {
+	return sr.dryRun
+}
+
+// dryRunResult completes the result of a command run by a dry-run runner. The plan is
+// masked first, so the live stdout writer does not see secrets either.
+func (sr *ScriptRunner) dryRunResult(command Command, result *ExecResult) *ExecResult {
+	return sr.planResult(sr.masker(&command).maskPlan(sr.PlanCommand(command)), result)
+}
+
+// planResult fills the result from the planned step. The command line is reported as
+// output and written to the live stdout writer.
+func (sr *ScriptRunner) planResult(planned PlanStep, result *ExecResult) *ExecResult {
+	result.DryRun = true
+	result.Script = planned.Script
+	result.Path = planned.Path
+	result.Dir = planned.Dir
+	result.Interpreter = planned.Interpreter
+	result.Argv = planned.Argv
+
+	if planned.Err != nil {
+		return result.fail(planned.Err)
+	}
+
+	line := "dry run: " + quoteArgs(planned.Argv) + "\n"
+	if stdout := sr.settings().stdout; stdout != nil {
+		stdout.Write([]byte(line))
+	}
+	result.Stdout = line
+	result.Combined = line
+	result.End = time.Now()
+	return result
+}
+
+// Err returns the problems found in the plan, nil if every step can run
+func (p Plan) Err() error {
+	var errs []error
+	for _, step := range p {
+		if step.Err != nil {
+			errs = append(errs, step.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String renders the plan as a markdown table
+func (p Plan) String() string {
+	table := NewMdTable([]string{"Step", "When", "Command", "Dir", "Env", "Problem"})
+	table.SetColumnFormatter(2, AddBackticks)
+	table.SetEmptyPlaceholder(4, "-")
+	table.SetEmptyPlaceholder(5, "-")
+
+	for i, step := range p {
+		position := step.Step
+		if position == "" {
+			position = strconv.Itoa(i + 1)
+		}
+
+		when := step.When
+		if step.AllowFailure {
+			when += " (may fail)"
+		}
+
+		command := quoteArgs(step.Argv)
+		if command == "" {
+			command = step.Script
+		}
+
+		// Only variable names are shown, values may hold secrets
+		var env []string
+		for _, kv := range step.EnvSet {
+			name, _, _ := strings.Cut(kv, "=")
+			env = append(env, "+"+name)
+		}
+		for _, name := range step.EnvUnset {
+			env = append(env, "-"+name)
+		}
+
+		problem := ""
+		if step.Err != nil {
+			problem = step.Err.Error()
+		}
+
+		table.AddRow([]string{position, when, command, step.Dir, strings.Join(env, " "), problem})
+	}
+
+	return table.Generate()
+}
+
+// envDiff returns the variables of env added or changed compared to base, and the names
+// of the base variables missing from env. Later duplicates win, like in exec.Cmd.
+func envDiff(base, env []string) (set []string, unset []string) {
+	toMap := func(list []string) map[string]string {
+		m := make(map[string]string, len(list))
+		for _, kv := range list {
+			name, value, _ := strings.Cut(kv, "=")
+			m[name] = value
+		}
+		return m
+	}
+	baseMap, envMap := toMap(base), toMap(env)
+
+	for name, value := range envMap {
+		if old, exists := baseMap[name]; !exists || old != value {
+			set = append(set, name+"="+value)
+		}
+	}
+	for name := range baseMap {
+		if _, exists := envMap[name]; !exists {
+			unset = append(unset, name)
+		}
+	}
+
+	sort.Strings(set)
+	sort.Strings(unset)
+	return set, unset
+}
+
+// quoteArgs joins a command line, quoting the arguments that need it
+func quoteArgs(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'$\\|&;<>()*?") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// String describes the planned step in a single line
+func (s PlanStep) String() string {
+	if s.Err != nil {
+		return fmt.Sprintf("%s: %v", s.Script, s.Err)
+	}
+	return fmt.Sprintf("%s (in %s)", quoteArgs(s.Argv), s.Dir)
+}
@@ -0,0 +1,120 @@
+package devscripts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"repodelete.sh": "#!/bin/bash\ntouch deleted.txt\n",
+	})
+	if err := os.Chmod(filepath.Join(tempDir, "repodelete.sh"), 0644); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+
+	var live strings.Builder
+	runner := NewScriptRunnerWithOptions(tempDir, WithDryRun(), WithOutput(&live, nil), WithEnv("GH_TOKEN=secret"))
+
+	exitCode, output, err := runner.ExecScript("repodelete.sh", "my repo", "force")
+	if exitCode != 0 || err != nil {
+		t.Fatalf("Expected dry run to succeed, got %d %v", exitCode, err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "deleted.txt")); !os.IsNotExist(err) {
+		t.Fatal("Dry run must not execute the script")
+	}
+	if info, _ := os.Stat(filepath.Join(tempDir, "repodelete.sh")); info.Mode().Perm() != 0644 {
+		t.Errorf("Dry run must not change the script mode, got %o", info.Mode().Perm())
+	}
+	if !strings.Contains(output, "dry run: "+shInterpreter("#!/bin/bash")+" ") || !strings.Contains(output, `"my repo" force`) {
+		t.Errorf("Expected the command line as output, got %q", output)
+	}
+	if live.String() != output {
+		t.Errorf("Expected the plan to be printed to the live writer, got %q", live.String())
+	}
+
+	result := runner.Run(context.Background(), Command{Script: "repodelete.sh"})
+	if !result.DryRun || result.Argv == nil || result.Interpreter != shInterpreter("#!/bin/bash") {
+		t.Errorf("Expected a resolved dry run result, got %+v", result)
+	}
+
+	// Missing scripts are reported without running anything
+	if exitCode, _, err := runner.ExecScript("missing.sh"); exitCode != 1 || err == nil {
+		t.Errorf("Expected missing script to be reported, got %d %v", exitCode, err)
+	}
+}
+
+func TestScriptChainPlan(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"tagalldelete.sh": "#!/bin/bash\ntouch ran.txt\n",
+		"cleanup.sh":      "#!/bin/bash\ntouch ran.txt\n",
+	})
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write notes.txt: %v", err)
+	}
+
+	t.Setenv("DEVSCRIPTS_DROPPED", "1")
+	runner := NewScriptRunnerWithOptions(tempDir, WithEnvAllowList("PATH"))
+
+	chain := runner.Chain().
+		ThenCommand(Command{Script: "tagalldelete.sh", Args: []string{"tags.txt"}, Env: []string{"GH_TOKEN=secret"}}).
+		Parallel(NewCommand("missing.sh"), NewCommand("notes.txt")).AllowFailure().
+		Finally("cleanup.sh")
+
+	plan := chain.Plan()
+	if len(plan) != 4 {
+		t.Fatalf("Expected 4 planned steps, got %d", len(plan))
+	}
+	if plan[0].Step != "1" || plan[1].Step != "2.1" || plan[2].Step != "2.2" || plan[3].When != "always" {
+		t.Errorf("Unexpected positions: %s %s %s %s", plan[0].Step, plan[1].Step, plan[2].Step, plan[3].When)
+	}
+	if !strings.Contains(strings.Join(plan[0].EnvSet, " "), "GH_TOKEN=secret") || !strings.Contains(strings.Join(plan[0].EnvUnset, " "), "DEVSCRIPTS_DROPPED") {
+		t.Errorf("Expected env diff, got set %v unset %v", plan[0].EnvSet, plan[0].EnvUnset)
+	}
+	if plan[1].Err == nil || plan[2].Err == nil || !plan[1].AllowFailure {
+		t.Errorf("Expected missing and unsupported scripts in the plan, got %v %v", plan[1].Err, plan[2].Err)
+	}
+	var unsupported *UnsupportedScriptError
+	if !errors.As(plan.Err(), &unsupported) {
+		t.Errorf("Expected plan errors to include the unsupported script, got %v", plan.Err())
+	}
+
+	text := plan.String()
+	for _, want := range []string{"tagalldelete.sh", "+GH_TOKEN", "-DEVSCRIPTS_DROPPED", "does not exist", "unsupported script type", "always"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected printed plan to contain %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "secret") {
+		t.Errorf("Printed plan must not show variable values:\n%s", text)
+	}
+
+	// A dry-run runner executes the chain as a plan
+	dry := NewScriptRunnerWithOptions(tempDir, WithDryRun())
+	results := dry.Chain().Then("tagalldelete.sh").Finally("cleanup.sh").Run(context.Background())
+	if len(results) != 2 || !results[0].DryRun || !results[1].DryRun {
+		t.Errorf("Expected dry run results for every step, got %d", len(results))
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "ran.txt")); !os.IsNotExist(err) {
+		t.Error("Dry run chain must not execute any script")
+	}
+	// Hooks apply to every command of a dry-run chain
+	var events bytes.Buffer
+	guarded := NewScriptRunnerWithOptions(tempDir, WithDryRun(), WithHooks(DenyScripts("cleanup.sh")), WithEventLog(&events))
+	exitCode, _, err := guarded.Chain().Then("tagalldelete.sh").Then("cleanup.sh").Execute()
+	if exitCode == 0 || !errors.Is(err, ErrExecutionVetoed) {
+		t.Errorf("Expected the dry-run chain to be vetoed, got %d %v", exitCode, err)
+	}
+	if lines := strings.Count(events.String(), "\n"); lines != 2 || !strings.Contains(events.String(), `"dry_run":true`) {
+		t.Errorf("Expected an event per dry-run command, got:\n%s", events.String())
+	}
+}
@@ -0,0 +1,9 @@
+package devscripts
+
+import "embed"
+
+// EmbeddedScripts holds the shell scripts of this package, so tools built on devscripts
+// can run them from a single binary with NewEmbeddedRunner
+//
+//go:embed *.sh
+var EmbeddedScripts embed.FS
@@ -0,0 +1,92 @@
+package devscripts
+
+import (
+	"os"
+	"strings"
+)
+
+// WithWorkDir sets the default working directory of every execution. Scripts are still
+// looked up in the scripts directory. When empty, the scripts directory is used.
+func WithWorkDir(dir string) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.workDir = dir
+	}
+}
+
+// WithEnv adds "KEY=value" variables to the environment of every execution
+func WithEnv(env ...string) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.env = append(sr.env, env...)
+	}
+}
+
+// WithEnvAllowList restricts the inherited environment to the named variables
+func WithEnvAllowList(names ...string) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.envAllow = append(sr.envAllow, names...)
+	}
+}
+
+// WithIsolatedEnv stops executions from inheriting the environment of the current process.
+// Only LANG=C and the variables added explicitly are set.
+func WithIsolatedEnv() RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.isolateEnv = true
+	}
+}
+
+// workDirFor returns the working directory of the command
+func (sr *ScriptRunner) workDirFor(command Command) string {
+	if command.Dir != "" {
+		return command.Dir
+	}
+	if sr.workDir != "" {
+		return sr.workDir
+	}
+	return sr.scriptsDir
+}
+
+// buildEnv returns the environment of the command. Later entries win, so the command
+// variables override the runner ones, which override the inherited environment.
+func (sr *ScriptRunner) buildEnv(command Command) []string {
+	var env []string
+
+	if !sr.isolateEnv && !command.IsolateEnv {
+		allow := sr.envAllow
+		if command.EnvAllow != nil {
+			allow = command.EnvAllow
+		}
+		env = filterEnv(os.Environ(), allow)
+	}
+
+	// Configure environment variables to ensure stability
+	env = append(env, "LANG=C")
+	env = append(env, sr.env...)
+	env = append(env, command.Env...)
+
+	// Scripts of a file system source their dependencies from the private directory
+	if sr.fs != nil {
+		env = append(env, sr.fsPath(env))
+	}
+
+	return env
+}
+
+// filterEnv keeps only the variables named in allow. A nil allow list keeps everything.
+func filterEnv(env, allow []string) []string {
+	if allow == nil {
+		return env
+	}
+
+	filtered := make([]string, 0, len(allow))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, allowed := range allow {
+			if name == allowed {
+				filtered = append(filtered, kv)
+				break
+			}
+		}
+	}
+	return filtered
+}
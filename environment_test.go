@@ -0,0 +1,105 @@
+package devscripts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkDirOptions(t *testing.T) {
+	scriptsDir := t.TempDir()
+	runnerDir := t.TempDir()
+	callDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(scriptsDir, "pwd.sh"), []byte("#!/bin/bash\npwd\n"), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	// Default: the scripts directory
+	_, output, _ := NewScriptRunner(scriptsDir).ExecScript("pwd.sh")
+	if !sameDir(t, output, scriptsDir) {
+		t.Errorf("Expected to run in %s, got %s", scriptsDir, output)
+	}
+
+	// Runner working directory
+	runner := NewScriptRunnerWithOptions(scriptsDir, WithWorkDir(runnerDir))
+	_, output, _ = runner.ExecScript("pwd.sh")
+	if !sameDir(t, output, runnerDir) {
+		t.Errorf("Expected to run in %s, got %s", runnerDir, output)
+	}
+
+	// Per-call working directory overrides the runner one
+	result := runner.Run(context.Background(), Command{Script: "pwd.sh", Dir: callDir})
+	if !sameDir(t, result.Stdout, callDir) || result.Dir != callDir {
+		t.Errorf("Expected to run in %s, got %s (%s)", callDir, result.Stdout, result.Dir)
+	}
+}
+
+func TestEnvOptions(t *testing.T) {
+	tempDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tempDir, "env.sh"), []byte(`#!/bin/bash
+echo "RUNNER=$RUNNER_VAR"
+echo "CALL=$CALL_VAR"
+echo "KEEP=$DEVSCRIPTS_KEEP"
+echo "DROP=$DEVSCRIPTS_DROP"
+echo "LANG=$LANG"
+`), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	t.Setenv("DEVSCRIPTS_KEEP", "kept")
+	t.Setenv("DEVSCRIPTS_DROP", "dropped")
+
+	// Runner env is overridden by call env and the environment is inherited
+	runner := NewScriptRunnerWithOptions(tempDir, WithEnv("RUNNER_VAR=runner", "CALL_VAR=runner"))
+	result := runner.Run(context.Background(), Command{Script: "env.sh", Env: []string{"CALL_VAR=call"}})
+	for _, want := range []string{"RUNNER=runner", "CALL=call", "KEEP=kept", "DROP=dropped", "LANG=C"} {
+		if !strings.Contains(result.Stdout, want+"\n") {
+			t.Errorf("Expected output to contain %q, got %q", want, result.Stdout)
+		}
+	}
+
+	// Allow list keeps only the named inherited variables
+	runner = NewScriptRunnerWithOptions(tempDir, WithEnvAllowList("DEVSCRIPTS_KEEP"))
+	result = runner.Run(context.Background(), Command{Script: "env.sh"})
+	if !strings.Contains(result.Stdout, "KEEP=kept\n") || !strings.Contains(result.Stdout, "DROP=\n") {
+		t.Errorf("Expected allow list to filter the environment, got %q", result.Stdout)
+	}
+
+	// Per-call allow list replaces the runner one
+	result = runner.Run(context.Background(), Command{Script: "env.sh", EnvAllow: []string{"DEVSCRIPTS_DROP"}})
+	if !strings.Contains(result.Stdout, "KEEP=\n") || !strings.Contains(result.Stdout, "DROP=dropped\n") {
+		t.Errorf("Expected call allow list to replace the runner one, got %q", result.Stdout)
+	}
+
+	// Isolated environment only has the explicit variables
+	runner = NewScriptRunnerWithOptions(tempDir, WithIsolatedEnv(), WithEnv("RUNNER_VAR=isolated"))
+	result = runner.Run(context.Background(), Command{Script: "env.sh"})
+	if !strings.Contains(result.Stdout, "RUNNER=isolated\n") || !strings.Contains(result.Stdout, "KEEP=\n") {
+		t.Errorf("Expected isolated environment, got %q", result.Stdout)
+	}
+
+	result = NewScriptRunner(tempDir).Run(context.Background(), Command{Script: "env.sh", IsolateEnv: true})
+	if !strings.Contains(result.Stdout, "KEEP=\n") || !strings.Contains(result.Stdout, "LANG=C\n") {
+		t.Errorf("Expected isolated call environment, got %q", result.Stdout)
+	}
+}
+
+// sameDir reports whether the printed directory is dir, resolving symlinks in temp paths
+func sameDir(t *testing.T, printed, dir string) bool {
+	t.Helper()
+	got, err := filepath.EvalSymlinks(strings.TrimSpace(printed))
+	if err != nil {
+		return false
+	}
+	want, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatalf("Failed to resolve %s: %v", dir, err)
+	}
+	return got == want
+}
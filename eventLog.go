@@ -0,0 +1,176 @@
+package devscripts
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// outputHashLength is the number of hex digits kept of the output hash
+const outputHashLength = 16
+
+// redactedValue replaces secrets in logged arguments
+const redactedValue = "***"
+
+// secretName matches the names of flags and variables that usually hold secrets
+var secretName = regexp.MustCompile(`(?i)token|secret|passw(or)?d|api_?key|auth|credential`)
+
+// Event is one line of the event log, describing a finished execution
+type Event struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user,omitempty"`
+	Script     string    `json:"script"`
+	Args       []string  `json:"args,omitempty"`
+	Dir        string    `json:"dir"`
+	DurationMS int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"`
+	Error      string    `json:"error,omitempty"`
+	OutputHash string    `json:"output_hash"`
+	DryRun     bool      `json:"dry_run,omitempty"` // The script was only resolved, not run
+}
+
+// EventLog is a hook that writes one JSON line per execution to a writer, e.g. an
+// append-only file. Arguments holding secrets are redacted.
+type EventLog struct {
+	mu   sync.Mutex
+	w    io.Writer
+	user string
+	err  error
+}
+
+// NewEventLog creates an event log writing to w
+func NewEventLog(w io.Writer) *EventLog {
+	el := &EventLog{w: w}
+	if u, err := user.Current(); err == nil {
+		el.user = u.Username
+	}
+	return el
+}
+
+// WithEventLog writes one JSON line per execution to w, see EventLog
+func WithEventLog(w io.Writer) RunnerOption {
+	return WithHooks(NewEventLog(w))
+}
+
+// BeforeExec implements Hook and never vetoes an execution
+func (el *EventLog) BeforeExec(ctx context.Context, plan PlanStep) error {
+	return nil
+}
+
+// AfterExec implements Hook by writing the event of the execution
+func (el *EventLog) AfterExec(ctx context.Context, plan PlanStep, result *ExecResult) {
+	event := Event{
+		Time:       result.Start.UTC(),
+		User:       el.user,
+		Script:     result.Script,
+		Args:       redactArgs(result.Args),
+		Dir:        result.Dir,
+		DurationMS: result.Duration().Milliseconds(),
+		ExitCode:   result.ExitCode,
+		OutputHash: outputHash(result.Combined),
+		DryRun:     result.DryRun,
+	}
+	if result.Err != nil {
+		event.Error = result.Err.Error()
+	}
+
+	// Event only holds plain fields, so encoding cannot fail
+	line, _ := json.Marshal(event)
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if _, err := el.w.Write(append(line, '\n')); err != nil && el.err == nil {
+		el.err = fmt.Errorf("error writing event: %w", err)
+	}
+}
+
+// Err returns the first error writing the log
+func (el *EventLog) Err() error {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	return el.err
+}
+
+// outputHash returns the truncated sha256 of the output, enough to compare runs without
+// storing what the script printed
+func outputHash(output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return hex.EncodeToString(sum[:])[:outputHashLength]
+}
+
+// redactArgs masks the values of arguments that look like secrets: NAME=value and
+// --name=value where the name suggests a secret, and the argument following such a flag
+func redactArgs(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		if name, _, found := strings.Cut(arg, "="); found && secretName.MatchString(name) {
+			redacted[i] = name + "=" + redactedValue
+			continue
+		}
+		if i > 0 && strings.HasPrefix(args[i-1], "-") && !strings.Contains(args[i-1], "=") && secretName.MatchString(args[i-1]) {
+			redacted[i] = redactedValue
+		}
+	}
+	return redacted
+}
+
+// EventFilter selects events when reading a log. Zero fields match everything.
+type EventFilter struct {
+	Script string    // Script name, with or without extension
+	Since  time.Time // Events at or after this time
+	Until  time.Time // Events before this time
+}
+
+// Match reports whether the event passes the filter
+func (f EventFilter) Match(event Event) bool {
+	if f.Script != "" && event.Script != f.Script && strings.TrimSuffix(event.Script, filepath.Ext(event.Script)) != f.Script {
+		return false
+	}
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !event.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// ReadEvents reads an event log and returns the events that match the filter, in order
+func ReadEvents(r io.Reader, filter EventFilter) ([]Event, error) {
+	var events []Event
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return events, fmt.Errorf("error decoding event on line %d: %w", line, err)
+		}
+		if filter.Match(event) {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return events, fmt.Errorf("error reading events: %w", err)
+	}
+	return events, nil
+}
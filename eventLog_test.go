@@ -0,0 +1,99 @@
+package devscripts
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventLog(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"repodelete.sh": "#!/bin/bash\necho \"deleting $1\"\n",
+		"fail.sh":       "#!/bin/bash\necho oops\nexit 3\n",
+	})
+
+	var log bytes.Buffer
+	events := NewEventLog(&log)
+	runner := NewScriptRunnerWithOptions(tempDir, WithHooks(events))
+
+	before := time.Now().Add(-time.Second)
+	runner.ExecScript("repodelete.sh", "old-repo", "--token", "ghp_secret", "GH_TOKEN=abc", "--password=hunter2")
+	runner.Chain().Then("fail.sh").Then("repodelete.sh").Execute()
+	runner.ExecScript("repodelete.sh", "other")
+
+	if err := events.Err(); err != nil {
+		t.Fatalf("Writing events failed: %v", err)
+	}
+	for _, secret := range []string{"ghp_secret", "abc", "hunter2"} {
+		if strings.Contains(log.String(), secret) {
+			t.Errorf("Secret %q leaked into the event log:\n%s", secret, log.String())
+		}
+	}
+	if lines := strings.Count(log.String(), "\n"); lines != 3 {
+		t.Fatalf("Expected one line per execution, got %d:\n%s", lines, log.String())
+	}
+
+	all, err := ReadEvents(strings.NewReader(log.String()), EventFilter{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Expected 3 events, got %d %v", len(all), err)
+	}
+
+	first := all[0]
+	wantArgs := "old-repo --token *** GH_TOKEN=*** --password=***"
+	if first.Script != "repodelete.sh" || strings.Join(first.Args, " ") != wantArgs || first.Dir != tempDir {
+		t.Errorf("Unexpected event: %+v", first)
+	}
+	if first.Time.Before(before) || first.DurationMS < 0 || len(first.OutputHash) != outputHashLength {
+		t.Errorf("Unexpected event timing or hash: %+v", first)
+	}
+	if all[1].ExitCode != 3 || all[1].Error == "" || all[1].OutputHash != outputHash("oops\n") {
+		t.Errorf("Expected the failure to be logged, got %+v", all[1])
+	}
+
+	// Query by script name, with or without extension
+	deleted, _ := ReadEvents(strings.NewReader(log.String()), EventFilter{Script: "repodelete"})
+	if len(deleted) != 2 || deleted[1].Args[0] != "other" {
+		t.Errorf("Expected the 2 repodelete.sh events, got %+v", deleted)
+	}
+
+	// Query by time range
+	none, _ := ReadEvents(strings.NewReader(log.String()), EventFilter{Until: before})
+	recent, _ := ReadEvents(strings.NewReader(log.String()), EventFilter{Script: "fail.sh", Since: before, Until: time.Now().Add(time.Second)})
+	if len(none) != 0 || len(recent) != 1 {
+		t.Errorf("Unexpected time range results: %d before, %d recent", len(none), len(recent))
+	}
+
+	if _, err := ReadEvents(strings.NewReader("{\"script\":\"a\"}\nnot json\n"), EventFilter{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected decoding error on line 2, got %v", err)
+	}
+}
+
+func TestWithEventLog(t *testing.T) {
+	var log bytes.Buffer
+	runner := NewScriptRunnerWithOptions(t.TempDir(), WithEventLog(&log))
+
+	runner.Run(context.Background(), Command{Script: "missing.sh"})
+	if !strings.Contains(log.String(), `"script":"missing.sh"`) {
+		t.Errorf("Expected scripts that cannot start to be logged too, got %q", log.String())
+	}
+}
+
+func TestEventLogDryRun(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{"repodelete.sh": "#!/bin/bash\necho deleted\n"})
+
+	var log bytes.Buffer
+	NewScriptRunnerWithOptions(tempDir, WithEventLog(&log), WithDryRun()).ExecScript("repodelete.sh", "old-repo")
+	NewScriptRunnerWithOptions(tempDir, WithEventLog(&log)).ExecScript("repodelete.sh", "old-repo")
+
+	events, err := ReadEvents(strings.NewReader(log.String()), EventFilter{Script: "repodelete.sh"})
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d %v", len(events), err)
+	}
+	if !events[0].DryRun || events[1].DryRun {
+		t.Errorf("Expected only the first event to be a dry run, got %+v", events)
+	}
+}
@@ -0,0 +1,96 @@
+package devscripts
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Command describes a single script execution
+type Command struct {
+	Script     string       // Script name relative to the scripts directory
+	Args       []string     // Arguments passed to the script
+	Dir        string       // Working directory, defaults to the runner one
+	Env        []string     // Extra "KEY=value" variables, applied after the runner ones
+	EnvAllow   []string     // Inherited variables to keep, replaces the runner allow list when not nil
+	IsolateEnv bool         // Do not inherit the environment of the current process
+	Stdin      io.Reader    // Standard input of the script, consumed by the first execution
+	Input      string       // Canned answers written to stdin when Stdin is nil, e.g. "y\n"
+	Retry      *RetryPolicy // Retry policy, replaces the runner one when not nil
+}
+
+// stdin returns the reader wired to the standard input of the script, nil for none
+func (c Command) stdin() io.Reader {
+	if c.Stdin != nil {
+		return c.Stdin
+	}
+	if c.Input != "" {
+		return strings.NewReader(c.Input)
+	}
+	return nil
+}
+
+// ExecResult holds everything known about a single script execution
+type ExecResult struct {
+	Script         string            // Script name as requested
+	Path           string            // Resolved path of the script file
+	Interpreter    string            // Interpreter command used to run the script
+	Args           []string          // Arguments passed to the script
+	Argv           []string          // Final command line, starting with the interpreter
+	Dir            string            // Working directory of the process
+	Stdout         string            // Captured standard output
+	Stderr         string            // Captured standard error
+	Combined       string            // Stdout and stderr merged in arrival order
+	Truncated      bool              // The captured output was cut at the WithMaxOutput limit
+	ExitCode       int               // Exit code of the process, 1 if it could not be started
+	Start          time.Time         // Time the execution started
+	End            time.Time         // Time the execution finished
+	Err            error             // Error of the execution, nil on success
+	ModeErr        error             // Non-fatal error making the script executable
+	SkipReason     string            // Why the step did not run, empty if it ran
+	FailureAllowed bool              // The chain step may fail without stopping the chain
+	Outputs        map[string]string // Declared outputs set by the chain step
+	Attempts       []*ExecResult     // Earlier failed attempts when the command was retried
+	DryRun         bool              // The command was only resolved, not executed
+}
+
+// Duration returns how long the execution took
+func (r *ExecResult) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Success reports whether the script ran and exited with code 0
+func (r *ExecResult) Success() bool {
+	return r.Err == nil && r.ExitCode == 0
+}
+
+// AttemptCount returns how many times the command was executed
+func (r *ExecResult) AttemptCount() int {
+	if r.Argv == nil {
+		return len(r.Attempts)
+	}
+	return len(r.Attempts) + 1
+}
+
+// Skipped reports whether the step was skipped instead of executed
+func (r *ExecResult) Skipped() bool {
+	return r.SkipReason != ""
+}
+
+// skip marks the result as not executed for the given reason
+func (r *ExecResult) skip(reason string) *ExecResult {
+	r.ExitCode = -1
+	r.SkipReason = reason
+	r.Err = fmt.Errorf("script '%s': %w: %s", r.Script, ErrStepSkipped, reason)
+	r.End = time.Now()
+	return r
+}
+
+// fail marks the result as failed before the script could be started
+func (r *ExecResult) fail(err error) *ExecResult {
+	r.ExitCode = 1
+	r.Err = err
+	r.End = time.Now()
+	return r
+}
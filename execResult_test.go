@@ -0,0 +1,141 @@
+package devscripts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunExecResult(t *testing.T) {
+	tempDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tempDir, "result.sh"), []byte(`#!/bin/bash
+echo "to stdout $1"
+echo "to stderr" >&2
+exit $2
+`), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	runner := NewScriptRunner(tempDir)
+
+	result := runner.Run(context.Background(), Command{Script: "result.sh", Args: []string{"hello", "3"}})
+
+	if result.Script != "result.sh" {
+		t.Errorf("Expected Script to be result.sh, got %q", result.Script)
+	}
+	if result.Path != filepath.Join(tempDir, "result.sh") {
+		t.Errorf("Unexpected Path: %q", result.Path)
+	}
+	if want := shInterpreter("#!/bin/bash"); result.Interpreter != want {
+		t.Errorf("Expected interpreter %q, got %q", want, result.Interpreter)
+	}
+	if result.Dir != tempDir {
+		t.Errorf("Expected Dir %q, got %q", tempDir, result.Dir)
+	}
+	if len(result.Argv) != 4 || result.Argv[0] != result.Interpreter || result.Argv[2] != "hello" {
+		t.Errorf("Unexpected Argv: %v", result.Argv)
+	}
+	if result.Stdout != "to stdout hello\n" {
+		t.Errorf("Unexpected Stdout: %q", result.Stdout)
+	}
+	if result.Stderr != "to stderr\n" {
+		t.Errorf("Unexpected Stderr: %q", result.Stderr)
+	}
+	if len(result.Combined) != len(result.Stdout)+len(result.Stderr) {
+		t.Errorf("Combined output should hold both streams, got %q", result.Combined)
+	}
+	if result.ExitCode != 3 || result.Err == nil || result.Success() {
+		t.Errorf("Expected failed result with exit code 3, got %d (%v)", result.ExitCode, result.Err)
+	}
+	if result.Start.IsZero() || result.End.Before(result.Start) || result.Duration() < 0 {
+		t.Errorf("Unexpected timing: start %v end %v", result.Start, result.End)
+	}
+
+	// A missing script is reported in the result without running anything
+	result = runner.Run(context.Background(), Command{Script: "missing.sh"})
+	if result.ExitCode != 1 || result.Err == nil || result.Argv != nil {
+		t.Errorf("Expected not started failure, got %+v", result)
+	}
+}
+
+func TestScriptChainResults(t *testing.T) {
+	tempDir := t.TempDir()
+
+	for name, code := range map[string]string{"ok.sh": "0", "fail.sh": "2", "never.sh": "0"} {
+		content := "#!/bin/bash\necho \"" + name + "\"\nexit " + code + "\n"
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	chain := NewScriptRunner(tempDir).Chain().
+		Then("ok.sh").
+		Then("fail.sh").
+		Then("never.sh")
+
+	results := chain.Run(context.Background())
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 step results, got %d", len(results))
+	}
+	if !results[0].Success() || results[0].Stdout != "ok.sh\n" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].ExitCode != 2 || results[1].Script != "fail.sh" {
+		t.Errorf("Unexpected second result: %+v", results[1])
+	}
+	if !results[2].Skipped() || results[2].Argv != nil {
+		t.Errorf("Expected never.sh to be skipped, got %+v", results[2])
+	}
+	if len(chain.Results()) != 3 || chain.ExitCode() != 2 {
+		t.Errorf("Chain accessors do not reflect the last run: %d results, exit code %d", len(chain.Results()), chain.ExitCode())
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	tempDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tempDir, "confirm.sh"), []byte(`#!/bin/bash
+read -p "Delete $1? (y/n): " answer
+read -p "Name: " name
+if [[ "$answer" != "y" ]]; then
+  echo "aborted"
+  exit 1
+fi
+echo "deleted $1 by $name"
+`), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	runner := NewScriptRunner(tempDir)
+
+	// Canned answers
+	result := runner.Run(context.Background(), Command{Script: "confirm.sh", Args: []string{"repo"}, Input: "y\ngopher\n"})
+	if !result.Success() || !strings.Contains(result.Stdout, "deleted repo by gopher") {
+		t.Errorf("Expected confirmation to be answered, got %d %v %q", result.ExitCode, result.Err, result.Combined)
+	}
+
+	// Reader as stdin
+	result = runner.Run(context.Background(), Command{Script: "confirm.sh", Args: []string{"repo"}, Stdin: strings.NewReader("n\n")})
+	if result.ExitCode != 1 || !strings.Contains(result.Stdout, "aborted") {
+		t.Errorf("Expected confirmation to be refused, got %d %q", result.ExitCode, result.Combined)
+	}
+
+	// Without stdin the prompt reads EOF instead of hanging
+	result = runner.Run(context.Background(), Command{Script: "confirm.sh", Args: []string{"repo"}})
+	if result.ExitCode != 1 {
+		t.Errorf("Expected prompt without stdin to fail, got %d %q", result.ExitCode, result.Combined)
+	}
+
+	// Chain steps take stdin through ThenCommand
+	_, output, err := runner.Chain().
+		ThenCommand(Command{Script: "confirm.sh", Args: []string{"one"}, Input: "y\nchain\n"}).
+		Execute()
+	if err != nil || !strings.Contains(output, "deleted one by chain") {
+		t.Errorf("Expected chain step to read stdin, got %v %q", err, output)
+	}
+}
@@ -0,0 +1,87 @@
+package devscripts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeRunner is an in-memory Runner for tests that returns canned results per script name
+type FakeRunner struct {
+	mu      sync.Mutex
+	results map[string][]ExecResult
+	served  map[string]int
+	calls   []Command
+}
+
+// NewFakeRunner creates a fake runner without canned results
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{
+		results: make(map[string][]ExecResult),
+		served:  make(map[string]int),
+	}
+}
+
+// Respond registers the results returned for the script, in order, the last one being
+// repeated. Combined defaults to Stdout followed by Stderr.
+func (f *FakeRunner) Respond(scriptName string, results ...ExecResult) *FakeRunner {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[scriptName] = append(f.results[scriptName], results...)
+	return f
+}
+
+// RespondOutput registers a result with the given exit code, output and error for the script
+func (f *FakeRunner) RespondOutput(scriptName string, exitCode int, output string, err error) *FakeRunner {
+	return f.Respond(scriptName, ExecResult{Stdout: output, ExitCode: exitCode, Err: err})
+}
+
+// Calls returns the commands run so far, in order
+func (f *FakeRunner) Calls() []Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Command(nil), f.calls...)
+}
+
+// ExecScript returns the canned exit code, output and error of the script
+func (f *FakeRunner) ExecScript(scriptName string, args ...string) (int, string, error) {
+	return f.ExecScriptContext(context.Background(), scriptName, args...)
+}
+
+// ExecScriptContext returns the canned exit code, output and error of the script
+func (f *FakeRunner) ExecScriptContext(ctx context.Context, scriptName string, args ...string) (int, string, error) {
+	result := f.Run(ctx, Command{Script: scriptName, Args: args})
+	return result.ExitCode, result.Combined, result.Err
+}
+
+// Run returns the next canned result of the command script. Scripts without canned results
+// fail with ErrNoFixture.
+func (f *FakeRunner) Run(ctx context.Context, command Command) *ExecResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, command)
+
+	canned := f.results[command.Script]
+	if len(canned) == 0 {
+		return notStarted(command).fail(fmt.Errorf("%w for script '%s'", ErrNoFixture, command.Script))
+	}
+
+	i := min(f.served[command.Script], len(canned)-1)
+	f.served[command.Script]++
+
+	result := canned[i]
+	result.Script = command.Script
+	result.Args = command.Args
+	result.Start = time.Now()
+	result.End = result.Start
+	if result.Combined == "" {
+		result.Combined = result.Stdout + result.Stderr
+	}
+	return &result
+}
+
+// Chain creates a new script execution chain run by the fake
+func (f *FakeRunner) Chain() *ScriptChain {
+	return NewChain(f)
+}
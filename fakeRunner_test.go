@@ -0,0 +1,52 @@
+package devscripts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var _ Runner = NewFakeRunner()
+
+func TestFakeRunner(t *testing.T) {
+	fake := NewFakeRunner().
+		RespondOutput("gitpush.sh", 0, "pushed\n", nil).
+		Respond("gotest.sh",
+			ExecResult{Stdout: "flaky\n", ExitCode: 1, Err: errors.New("exit status 1")},
+			ExecResult{Stdout: "ok\n"},
+		)
+
+	code, output, err := fake.ExecScript("gitpush.sh", "msg")
+	if code != 0 || output != "pushed\n" || err != nil {
+		t.Errorf("Unexpected canned result: %d %q %v", code, output, err)
+	}
+
+	// Canned results are served in order, the last one repeating
+	for _, want := range []int{1, 0, 0} {
+		if code, _, _ := fake.ExecScript("gotest.sh"); code != want {
+			t.Errorf("Expected exit code %d, got %d", want, code)
+		}
+	}
+
+	if _, _, err := fake.ExecScript("unknown.sh"); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("Expected ErrNoFixture for a script without results, got %v", err)
+	}
+
+	// Chains built on the fake run their steps through it
+	fake = NewFakeRunner().
+		RespondOutput("build.sh", 0, "built\n", nil).
+		RespondOutput("deploy.sh", 2, "denied\n", errors.New("exit status 2"))
+	code, output, err = fake.Chain().
+		Then("build.sh").
+		Then("deploy.sh", "prod").
+		Then("notify.sh").
+		Execute()
+	if code != 2 || err == nil || !strings.Contains(output, "built") || !strings.Contains(output, "denied") {
+		t.Errorf("Unexpected chain result: %d %q %v", code, output, err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 2 || calls[1].Script != "deploy.sh" || calls[1].Args[0] != "prod" {
+		t.Errorf("Expected build.sh and deploy.sh to be called, got %+v", calls)
+	}
+}
@@ -0,0 +1,203 @@
+package devscripts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoFixture is returned (wrapped) when a replay runner has no recording for a command
+var ErrNoFixture = errors.New("no fixture recorded")
+
+// Fixture is a recorded execution, stored in fixture files by a Recorder
+type Fixture struct {
+	Script   string        `json:"script"`
+	Args     []string      `json:"args,omitempty"`
+	Argv     []string      `json:"argv,omitempty"`
+	Env      []string      `json:"env,omitempty"`
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	Combined string        `json:"combined"`
+	ExitCode int           `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// fixtureFile is the content of a fixture file
+type fixtureFile struct {
+	Executions []Fixture `json:"executions"`
+}
+
+// Recorder is a hook that saves every execution of a runner to a fixture file, to be served
+// later by a ReplayRunner. Register it with WithHooks.
+type Recorder struct {
+	path     string
+	envNames []string
+
+	mu   sync.Mutex
+	file fixtureFile
+	err  error
+}
+
+// NewRecorder creates a recorder writing to the fixture file at path. Only the environment
+// variables named in envNames are recorded, so secrets are not written by default.
+func NewRecorder(path string, envNames ...string) *Recorder {
+	return &Recorder{path: path, envNames: envNames}
+}
+
+// BeforeExec implements Hook and never vetoes an execution
+func (r *Recorder) BeforeExec(ctx context.Context, plan PlanStep) error {
+	return nil
+}
+
+// AfterExec implements Hook by appending the execution to the fixture file
+func (r *Recorder) AfterExec(ctx context.Context, plan PlanStep, result *ExecResult) {
+	fixture := Fixture{
+		Script:   result.Script,
+		Args:     result.Args,
+		Argv:     result.Argv,
+		Env:      r.env(plan),
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		Combined: result.Combined,
+		ExitCode: result.ExitCode,
+		Duration: result.Duration(),
+	}
+	if result.Err != nil {
+		fixture.Error = result.Err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file.Executions = append(r.file.Executions, fixture)
+	if err := r.save(); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error writing the fixture file
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// env returns the recorded variables as seen by the script
+func (r *Recorder) env(plan PlanStep) []string {
+	var env []string
+	for _, name := range r.envNames {
+		if i := slices.IndexFunc(plan.EnvSet, func(kv string) bool { return strings.HasPrefix(kv, name+"=") }); i >= 0 {
+			env = append(env, plan.EnvSet[i])
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok && !slices.Contains(plan.EnvUnset, name) {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// save writes the fixture file, replacing it atomically
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding fixtures: %w", err)
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing fixtures: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("error writing fixtures: %w", err)
+	}
+	return nil
+}
+
+// ReplayRunner serves recorded executions instead of running scripts
+type ReplayRunner struct {
+	mu       sync.Mutex
+	fixtures []Fixture
+	used     []bool
+}
+
+// NewReplayRunner loads the fixture file written by a Recorder at path
+func NewReplayRunner(path string) (*ReplayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixtures: %w", err)
+	}
+
+	var file fixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error decoding fixtures %s: %w", path, err)
+	}
+
+	return &ReplayRunner{fixtures: file.Executions, used: make([]bool, len(file.Executions))}, nil
+}
+
+// ExecScript returns the recorded exit code, output and error of the script
+func (rr *ReplayRunner) ExecScript(scriptName string, args ...string) (int, string, error) {
+	return rr.ExecScriptContext(context.Background(), scriptName, args...)
+}
+
+// ExecScriptContext returns the recorded exit code, output and error of the script
+func (rr *ReplayRunner) ExecScriptContext(ctx context.Context, scriptName string, args ...string) (int, string, error) {
+	result := rr.Run(ctx, Command{Script: scriptName, Args: args})
+	return result.ExitCode, result.Combined, result.Err
+}
+
+// Run returns the recorded result of the command. Recordings of the same script and
+// arguments are served in the order they were recorded, the last one being repeated.
+func (rr *ReplayRunner) Run(ctx context.Context, command Command) *ExecResult {
+	result := notStarted(command)
+
+	fixture, ok := rr.next(command)
+	if !ok {
+		return result.fail(fmt.Errorf("%w for script '%s' with args %q", ErrNoFixture, command.Script, command.Args))
+	}
+
+	result.Argv = fixture.Argv
+	result.Stdout = fixture.Stdout
+	result.Stderr = fixture.Stderr
+	result.Combined = fixture.Combined
+	result.ExitCode = fixture.ExitCode
+	result.End = result.Start.Add(fixture.Duration)
+	if fixture.Error != "" {
+		result.Err = errors.New(fixture.Error)
+	}
+	return result
+}
+
+// Chain creates a new script execution chain served from the fixtures
+func (rr *ReplayRunner) Chain() *ScriptChain {
+	return NewChain(rr)
+}
+
+// next returns the fixture that answers the command
+func (rr *ReplayRunner) next(command Command) (Fixture, bool) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	last := -1
+	for i, fixture := range rr.fixtures {
+		if fixture.Script != command.Script || !slices.Equal(fixture.Args, command.Args) {
+			continue
+		}
+		if !rr.used[i] {
+			rr.used[i] = true
+			return fixture, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Fixture{}, false
+	}
+	return rr.fixtures[last], true
+}
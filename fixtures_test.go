@@ -0,0 +1,64 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	_ Runner = NewScriptRunner()
+	_ Runner = (*ReplayRunner)(nil)
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"count.sh": "#!/bin/bash\nn=$(( $(cat counter 2>/dev/null || echo 0) + 1 ))\necho $n > counter\necho \"run $n $1\"\necho \"token $TOKEN\" >&2\nexit $2\n",
+	})
+	fixtures := filepath.Join(t.TempDir(), "fixtures.json")
+
+	recorder := NewRecorder(fixtures, "TARGET")
+	runner := NewScriptRunnerWithOptions(tempDir, WithHooks(recorder), WithEnv("TARGET=prod", "TOKEN=secret"))
+
+	runner.ExecScript("count.sh", "a", "0")
+	runner.ExecScript("count.sh", "a", "0")
+	runner.ExecScript("count.sh", "b", "3")
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("Recording failed: %v", err)
+	}
+
+	data := readFile(t, fixtures)
+	if !strings.Contains(data, "TARGET=prod") || strings.Contains(data, "TOKEN=") {
+		t.Errorf("Expected only the listed environment to be recorded:\n%s", data)
+	}
+
+	// Remove the scripts: replay must not need them
+	os.RemoveAll(tempDir)
+
+	replay, err := NewReplayRunner(fixtures)
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	// Repeated calls are served in recording order, the last one repeating
+	for _, want := range []string{"run 1 a\n", "run 2 a\n", "run 2 a\n"} {
+		result := replay.Run(context.Background(), Command{Script: "count.sh", Args: []string{"a", "0"}})
+		if !result.Success() || result.Stdout != want || result.Stderr != "token secret\n" || len(result.Argv) == 0 {
+			t.Errorf("Expected replayed %q, got %+v", want, result)
+		}
+	}
+
+	code, output, err := replay.ExecScript("count.sh", "b", "3")
+	if code != 3 || err == nil || !strings.Contains(output, "run 3 b") {
+		t.Errorf("Expected recorded failure, got %d %q %v", code, output, err)
+	}
+
+	_, _, err = replay.ExecScript("count.sh", "c", "0")
+	if !errors.Is(err, ErrNoFixture) {
+		t.Errorf("Expected ErrNoFixture for unrecorded args, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package devscripts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sourceLine matches the shell lines that load another script, e.g. `source functions.sh`
+// or `. "$(dirname "$0")/gocurrentdir.sh"`
+var sourceLine = regexp.MustCompile(`(?m)^\s*(?:source|\.)\s+(.+)$`)
+
+// sourcedName matches the script file names in the argument of a source line
+var sourcedName = regexp.MustCompile(`[\w.-]+(?:/[\w.-]+)*\.sh\b`)
+
+// fsScripts materialises the scripts of a file system into a private directory
+type fsScripts struct {
+	fsys   fs.FS
+	copied map[string]bool // Names already copied from fsys
+}
+
+// NewFSRunner creates a runner for the scripts of fsys. A script is copied, with the scripts
+// it sources, into a private temporary directory the first time it runs, and that directory
+// is searched after the WithSearchPaths directories. Close removes it.
+func NewFSRunner(fsys fs.FS, opts ...RunnerOption) (*ScriptRunner, error) {
+	dir, err := os.MkdirTemp("", "devscripts-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating scripts directory: %w", err)
+	}
+
+	sr := NewScriptRunnerWithOptions(dir, opts...)
+	sr.fs = &fsScripts{fsys: fsys, copied: make(map[string]bool)}
+	return sr, nil
+}
+
+// NewEmbeddedRunner creates a runner for the scripts embedded in this package
+func NewEmbeddedRunner(opts ...RunnerOption) (*ScriptRunner, error) {
+	return NewFSRunner(EmbeddedScripts, opts...)
+}
+
+// Close removes the scripts materialised by a runner created with NewFSRunner.
+// It does nothing for runners of a directory.
+func (sr *ScriptRunner) Close() error {
+	if sr.fs == nil {
+		return nil
+	}
+
+	sr.fsMu.Lock()
+	defer sr.fsMu.Unlock()
+	sr.fs.copied = make(map[string]bool)
+	return os.RemoveAll(sr.scriptsDir)
+}
+
+// materialise copies the script and the scripts it sources from the file system of the runner
+// into scriptsDir. Names missing from the file system are left to the search paths.
+func (sr *ScriptRunner) materialise(scriptName string) error {
+	if sr.fs == nil {
+		return nil
+	}
+
+	sr.fsMu.Lock()
+	defer sr.fsMu.Unlock()
+
+	for _, name := range sr.candidateNames(scriptName) {
+		if info, err := fs.Stat(sr.fs.fsys, name); err == nil && !info.IsDir() {
+			return sr.copyScript(name)
+		}
+	}
+	return nil
+}
+
+// copyScript copies one script and, recursively, the scripts it sources
+func (sr *ScriptRunner) copyScript(name string) error {
+	if sr.fs.copied[name] {
+		return nil
+	}
+
+	content, err := fs.ReadFile(sr.fs.fsys, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Sourced scripts may come from the system, e.g. /etc/os-release
+			return nil
+		}
+		return fmt.Errorf("error reading script '%s': %w", name, err)
+	}
+
+	target := filepath.Join(sr.scriptsDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return fmt.Errorf("error materialising script '%s': %w", name, err)
+	}
+	if err := os.WriteFile(target, content, 0700); err != nil {
+		return fmt.Errorf("error materialising script '%s': %w", name, err)
+	}
+	sr.fs.copied[name] = true
+
+	for _, dep := range sourcedScripts(content) {
+		if err := sr.copyScript(path.Join(path.Dir(name), dep)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourcedScripts returns the names of the .sh files loaded with source or . by the script
+func sourcedScripts(content []byte) []string {
+	var names []string
+	for _, match := range sourceLine.FindAllSubmatch(content, -1) {
+		arg, _, _ := strings.Cut(string(match[1]), "#")
+		names = append(names, sourcedName.FindAllString(arg, -1)...)
+	}
+	return names
+}
+
+// fsPath returns the PATH entry that lets scripts source their dependencies by bare name
+// from any working directory
+func (sr *ScriptRunner) fsPath(env []string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if value, ok := strings.CutPrefix(env[i], "PATH="); ok {
+			return "PATH=" + sr.scriptsDir + string(os.PathListSeparator) + value
+		}
+	}
+	return "PATH=" + sr.scriptsDir
+}
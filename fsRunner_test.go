@@ -0,0 +1,94 @@
+package devscripts
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSRunner(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.sh":       {Data: []byte("#!/bin/bash\nsource helpers.sh\n. \"$(dirname \"$0\")/lib/greet.sh\" # greeting\ngreet \"$1\"\n")},
+		"helpers.sh":    {Data: []byte("#!/bin/bash\nsource functions.sh\n")},
+		"functions.sh":  {Data: []byte("#!/bin/bash\nshout() { echo \"${1^^}\"; }\n")},
+		"lib/greet.sh":  {Data: []byte("#!/bin/bash\ngreet() { shout \"hello $1\"; }\n")},
+		"unrelated.sh":  {Data: []byte("#!/bin/bash\necho unrelated\n")},
+		"notascript.md": {Data: []byte("# docs\n")},
+	}
+
+	workDir := t.TempDir()
+	runner, err := NewFSRunner(fsys, WithWorkDir(workDir))
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	dir := runner.scriptsDir
+
+	// Sourced scripts are found by bare name even outside the scripts directory
+	code, output, err := runner.ExecScript("main", "gopher")
+	if err != nil || code != 0 || output != "HELLO GOPHER\n" {
+		t.Fatalf("Expected main.sh to run with its dependencies, got %d %q %v", code, output, err)
+	}
+
+	for _, name := range []string{"main.sh", "helpers.sh", "functions.sh", "lib/greet.sh"} {
+		if _, err := os.Stat(dir + "/" + name); err != nil {
+			t.Errorf("Expected %s to be materialised: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(dir + "/unrelated.sh"); err == nil {
+		t.Errorf("Expected unrelated.sh not to be materialised before it runs")
+	}
+
+	if _, _, err := runner.ExecScript("missing.sh"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected missing script error, got %v", err)
+	}
+
+	if err := runner.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected Close to remove %s", dir)
+	}
+}
+
+func TestEmbeddedRunner(t *testing.T) {
+	runner, err := NewEmbeddedRunner()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	code, output, err := runner.ExecScript("testScript.sh", "embedded")
+	if err != nil || code != 0 || !strings.Contains(output, "Received arguments: embedded") {
+		t.Errorf("Expected embedded testScript.sh to run, got %d %q %v", code, output, err)
+	}
+
+	scripts, err := NewScriptParserFS(EmbeddedScripts).GetScriptNames()
+	if err != nil {
+		t.Fatalf("Failed to list embedded scripts: %v", err)
+	}
+	for _, want := range []string{"functions.sh", "gomodutils.sh", "fileIssues.sh"} {
+		found := false
+		for _, name := range scripts {
+			found = found || name == want
+		}
+		if !found {
+			t.Errorf("Expected %s to be embedded, got %v", want, scripts)
+		}
+	}
+}
+
+func TestSourcedScripts(t *testing.T) {
+	content := []byte(`#!/bin/bash
+source functions.sh
+  . githubutils.sh
+source "$(dirname "$0")/gocurrentdir.sh"
+# source commented.sh
+echo "source not.sh"
+source /etc/os-release
+`)
+	got := strings.Join(sourcedScripts(content), ",")
+	if got != "functions.sh,githubutils.sh,gocurrentdir.sh" {
+		t.Errorf("Unexpected sourced scripts: %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrExecutionVetoed is returned (wrapped) when a hook refuses to run a script
+var ErrExecutionVetoed = errors.New("execution vetoed")
+
+// Hook observes every script execution of a runner, including the ones of chains and graphs.
+// Hooks may be called concurrently when steps run in parallel.
+type Hook interface {
+	// BeforeExec is called with the resolved command before the script starts.
+	// Returning an error vetoes the execution.
+	BeforeExec(ctx context.Context, plan PlanStep) error
+	// AfterExec is called with the result of every execution, including vetoed ones
+	AfterExec(ctx context.Context, plan PlanStep, result *ExecResult)
+}
+
+// HookFuncs adapts plain functions to the Hook interface. Nil functions are ignored.
+type HookFuncs struct {
+	Before func(ctx context.Context, plan PlanStep) error
+	After  func(ctx context.Context, plan PlanStep, result *ExecResult)
+}
+
+// BeforeExec calls Before if it is set
+func (h HookFuncs) BeforeExec(ctx context.Context, plan PlanStep) error {
+	if h.Before == nil {
+		return nil
+	}
+	return h.Before(ctx, plan)
+}
+
+// AfterExec calls After if it is set
+func (h HookFuncs) AfterExec(ctx context.Context, plan PlanStep, result *ExecResult) {
+	if h.After != nil {
+		h.After(ctx, plan, result)
+	}
+}
+
+// WithHooks registers hooks called around every execution, in the given order
+func WithHooks(hooks ...Hook) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.Use(hooks...)
+	}
+}
+
+// Use registers hooks called around every execution, in the given order
+func (sr *ScriptRunner) Use(hooks ...Hook) *ScriptRunner {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.hooks = append(slices.Clip(sr.hooks), hooks...)
+	return sr
+}
+
+// DenyScripts returns a hook that vetoes the given scripts, e.g. DenyScripts("repodelete.sh").
+// Names are compared by base name, with or without extension, against both the requested
+// script and the file it resolved to, so a bare name cannot get around the list.
+func DenyScripts(names ...string) Hook {
+	return HookFuncs{
+		Before: func(ctx context.Context, plan PlanStep) error {
+			for _, requested := range []string{plan.Script, plan.Path} {
+				if requested == "" {
+					continue
+				}
+				base := filepath.Base(requested)
+				if slices.Contains(names, base) || slices.Contains(names, strings.TrimSuffix(base, filepath.Ext(base))) {
+					return fmt.Errorf("script '%s' is on the deny list", plan.Script)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// runHooked runs the command through the registered hooks. With redaction enabled hooks
+// get a masked plan and result.
+func (sr *ScriptRunner) runHooked(ctx context.Context, command Command, execute func(context.Context, Command) *ExecResult) *ExecResult {
+	hooks := sr.settings().hooks
+	if len(hooks) == 0 {
+		return execute(ctx, command)
+	}
+
+	plan := sr.masker(&command).maskPlan(sr.PlanCommand(command))
+
+	var result *ExecResult
+	for _, hook := range hooks {
+		if err := hook.BeforeExec(ctx, plan); err != nil {
+			result = notStarted(command)
+			result.Path = plan.Path
+			result.Dir = plan.Dir
+			result.Interpreter = plan.Interpreter
+			result.fail(fmt.Errorf("%w: %w", ErrExecutionVetoed, err))
+			result = sr.redactResult(command, result)
+			break
+		}
+	}
+
+	if result == nil {
+		result = execute(ctx, command)
+	}
+
+	for _, hook := range hooks {
+		hook.AfterExec(ctx, plan, result)
+	}
+	return result
+}
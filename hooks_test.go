@@ -0,0 +1,85 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"build.sh":      "#!/bin/bash\necho \"build $1\"\n",
+		"repodelete.sh": "#!/bin/bash\necho deleted\n",
+	})
+
+	var mu sync.Mutex
+	var audit []string
+	logger := HookFuncs{
+		Before: func(ctx context.Context, plan PlanStep) error {
+			mu.Lock()
+			defer mu.Unlock()
+			audit = append(audit, "before "+strings.Join(plan.Argv[1:], " "))
+			return nil
+		},
+		After: func(ctx context.Context, plan PlanStep, result *ExecResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			audit = append(audit, "after "+plan.Script+" "+result.Stdout)
+		},
+	}
+
+	runner := NewScriptRunnerWithOptions(tempDir, WithHooks(DenyScripts("repodelete")), WithHooks(logger))
+
+	code, output, err := runner.ExecScript("build.sh", "app")
+	if err != nil || code != 0 || output != "build app\n" {
+		t.Fatalf("Expected build.sh to run, got %d %q %v", code, output, err)
+	}
+
+	// The deny list vetoes the script before it starts; later hooks still see the result
+	result := runner.Run(context.Background(), Command{Script: "repodelete.sh"})
+	if !errors.Is(result.Err, ErrExecutionVetoed) || result.Argv != nil || result.Stdout != "" {
+		t.Errorf("Expected repodelete.sh to be vetoed, got %+v", result)
+	}
+
+	want := []string{
+		"before " + tempDir + "/build.sh app",
+		"after build.sh build app\n",
+		"after repodelete.sh ",
+	}
+	if strings.Join(audit, "|") != strings.Join(want, "|") {
+		t.Errorf("Unexpected hook calls:\n got %q\nwant %q", audit, want)
+	}
+
+	// Chain steps go through the hooks too and a veto fails the step
+	_, _, err = runner.Chain().
+		Then("build.sh", "lib").
+		Then("repodelete.sh").
+		Then("build.sh", "never").
+		Execute()
+	if !errors.Is(err, ErrExecutionVetoed) {
+		t.Errorf("Expected chain to stop at the vetoed step, got %v", err)
+	}
+	if last := audit[len(audit)-1]; last != "after repodelete.sh " {
+		t.Errorf("Expected no execution after the vetoed step, last call %q", last)
+	}
+}
+
+func TestDenyScriptsResolvedName(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{"repodelete.sh": "#!/bin/bash\necho deleted\n"})
+
+	for _, denied := range []string{"repodelete.sh", "repodelete"} {
+		runner := NewScriptRunnerWithOptions(tempDir, WithHooks(DenyScripts(denied)))
+
+		// A bare name resolving to a denied script is vetoed too
+		for _, script := range []string{"repodelete", "repodelete.sh"} {
+			result := runner.Run(context.Background(), Command{Script: script})
+			if !errors.Is(result.Err, ErrExecutionVetoed) || result.Stdout != "" {
+				t.Errorf("Deny %q: expected %q to be vetoed, got success=%v out=%q", denied, script, result.Success(), result.Stdout)
+			}
+		}
+	}
+}
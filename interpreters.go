@@ -0,0 +1,107 @@
+package devscripts
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Interpreter is the program used to run scripts of a given file extension.
+// The script path is appended after Args, followed by the script arguments.
+type Interpreter struct {
+	Command string   // Program to execute, e.g. "bash" or "go"
+	Args    []string // Arguments placed before the script path, e.g. ["run"] for go
+}
+
+// UnsupportedScriptError is returned when no interpreter is registered for a script
+type UnsupportedScriptError struct {
+	Script     string   // Script name as requested
+	Ext        string   // Extension of the script, empty if it has none
+	Registered []string // Sorted list of registered extensions
+}
+
+func (e *UnsupportedScriptError) Error() string {
+	return fmt.Sprintf("unsupported script type: %q for '%s', registered extensions: %s",
+		e.Ext, e.Script, strings.Join(e.Registered, ", "))
+}
+
+// defaultInterpreters returns the interpreters known by a new runner
+func defaultInterpreters() map[string]Interpreter {
+	interpreters := map[string]Interpreter{
+		".sh": {Command: "bash"},
+		".py": {Command: "python"},
+		".go": {Command: "go", Args: []string{"run"}},
+	}
+
+	// Adjustment for Windows
+	if runtime.GOOS == "windows" {
+		interpreters[".sh"] = Interpreter{Command: `C:\Program Files\Git\bin\bash.exe`}
+	}
+
+	return interpreters
+}
+
+// normalizeExt makes "sh" and ".sh" equivalent
+func normalizeExt(ext string) string {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		return "." + ext
+	}
+	return ext
+}
+
+// RegisterInterpreter registers (or replaces) the interpreter used for scripts with the
+// given extension, e.g. RegisterInterpreter(".py", "python3") or RegisterInterpreter("js", "node").
+// A registered interpreter wins over the #! line of the scripts.
+func (sr *ScriptRunner) RegisterInterpreter(ext, command string, args ...string) *ScriptRunner {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	// Replace the maps so snapshots taken by running executions stay unchanged
+	ext = normalizeExt(ext)
+	interpreters := maps.Clone(sr.interpreters)
+	interpreters[ext] = Interpreter{Command: command, Args: args}
+	sr.interpreters = interpreters
+
+	registered := maps.Clone(sr.registered)
+	if registered == nil {
+		registered = map[string]bool{}
+	}
+	registered[ext] = true
+	sr.registered = registered
+	return sr
+}
+
+// Extensions returns the sorted list of registered script extensions
+func (sr *ScriptRunner) Extensions() []string {
+	interpreters := sr.settings().interpreters
+	exts := make([]string, 0, len(interpreters))
+	for ext := range interpreters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// lookupInterpreter returns the interpreter for the script, using its #! line and its
+// extension in the order selected by the runner precedence. Registered extensions
+// always come first.
+func (sr *ScriptRunner) lookupInterpreter(scriptName, scriptPath string) (Interpreter, error) {
+	ext := filepath.Ext(scriptName)
+	settings := sr.settings()
+	byExt, supported := settings.interpreters[ext]
+
+	shebangFirst := sr.precedence == PreferShebang && !settings.registered[ext]
+	if sr.precedence != ExtensionOnly && (shebangFirst || !supported) {
+		if shebang, found := readShebang(scriptPath); found {
+			return shebang, nil
+		}
+	}
+
+	if !supported {
+		return Interpreter{}, &UnsupportedScriptError{Script: scriptName, Ext: ext, Registered: sr.Extensions()}
+	}
+	return byExt, nil
+}
@@ -0,0 +1,100 @@
+package devscripts
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterInterpreter(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A fake interpreter script lets us check the argv without depending on other languages
+	err := os.WriteFile(filepath.Join(tempDir, "hello.txt"), []byte("hello from txt\n"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	runner := NewScriptRunnerWithOptions(tempDir, WithInterpreter("txt", "cat", "-n"))
+
+	if _, exists := runner.interpreters[".txt"]; !exists {
+		t.Fatal("Expected extension without dot to be registered as .txt")
+	}
+
+	exitCode, output, err := runner.ExecScript("hello.txt")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("Expected success, got exit code %d and error %v", exitCode, err)
+	}
+	if !strings.Contains(output, "1\thello from txt") {
+		t.Errorf("Expected interpreter args to be placed before the script, got %q", output)
+	}
+
+	// Replace the interpreter of an existing extension
+	runner.RegisterInterpreter(".py", "python3")
+	if runner.interpreters[".py"].Command != "python3" {
+		t.Errorf("Expected .py to use python3, got %q", runner.interpreters[".py"].Command)
+	}
+}
+
+func TestUnsupportedScriptError(t *testing.T) {
+	tempDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "script.rb"), []byte("puts 'hi'\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	runner := NewScriptRunner(tempDir)
+
+	exitCode, _, err := runner.ExecScript("script.rb")
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+
+	var unsupported *UnsupportedScriptError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedScriptError, got %v", err)
+	}
+	if unsupported.Ext != ".rb" {
+		t.Errorf("Expected extension .rb, got %q", unsupported.Ext)
+	}
+	if strings.Join(unsupported.Registered, ",") != strings.Join(runner.Extensions(), ",") {
+		t.Errorf("Expected registered extensions %v, got %v", runner.Extensions(), unsupported.Registered)
+	}
+	if !strings.Contains(err.Error(), ".sh") {
+		t.Errorf("Expected error to list registered extensions, got %v", err)
+	}
+}
+
+func TestGoScript(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	tempDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tempDir, "hello.go"), []byte(`package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Println("Hello", os.Args[1])
+}
+`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	exitCode, output, err := NewScriptRunner(tempDir).ExecScript("hello.go", "gopher")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("Expected success, got exit code %d, error %v, output %q", exitCode, err, output)
+	}
+	if !strings.Contains(output, "Hello gopher") {
+		t.Errorf("Expected output to contain greeting, got %q", output)
+	}
+}
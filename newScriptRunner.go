@@ -1,156 +1,347 @@
 package devscripts
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
-// scriptRunner is a handler for executing different types of scripts
-type scriptRunner struct {
-	scriptsDir   string            // Base directory of scripts
-	interpreters map[string]string // Map of file extensions to interpreter commands
+// ErrScriptTimeout is returned (wrapped) when a script is stopped because its deadline expired.
+// Use errors.Is(err, ErrScriptTimeout) to detect it.
+var ErrScriptTimeout = errors.New("script execution timed out")
+
+// waitDelay bounds how long we wait for output pipes to close after the script process
+// has been killed, so orphaned children holding stdout open cannot block forever.
+const waitDelay = 2 * time.Second
+
+// Runner executes scripts. It is implemented by ScriptRunner, ReplayRunner and FakeRunner,
+// so code using scripts can be tested without running them.
+type Runner interface {
+	ExecScript(scriptName string, args ...string) (int, string, error)
+	ExecScriptContext(ctx context.Context, scriptName string, args ...string) (int, string, error)
+	Run(ctx context.Context, command Command) *ExecResult
+	Chain() *ScriptChain
 }
 
-// NewScriptRunner creates a handler for scripts using an optional scripts directory parameter.
-// If no directory is provided, it uses the current working directory.
-func NewScriptRunner(scriptsDir ...string) *scriptRunner {
-	// Default value: scriptsDir is the current path
-	wd, err := os.Getwd()
-	if err != nil {
-		wd = "."
-	}
+// ScriptRunner is a handler for executing different types of scripts.
+// It is safe for concurrent use, including its setters.
+type ScriptRunner struct {
+	mu sync.RWMutex // Guards the settings that may change after creation
+
+	scriptsDir     string                 // Base directory of scripts
+	searchPaths    []string               // Directories searched before scriptsDir
+	interpreters   map[string]Interpreter // Map of file extensions to interpreters
+	registered     map[string]bool        // Extensions registered by the user, they win over #! lines
+	timeout        time.Duration          // Default timeout per execution, zero means no timeout
+	stdout         io.Writer              // Optional live destination of the scripts stdout
+	stderr         io.Writer              // Optional live destination of the scripts stderr
+	onLine         LineHandler            // Optional callback for each line of output
+	precedence     InterpreterPrecedence  // Whether #! lines or extensions choose the interpreter
+	workDir        string                 // Default working directory, empty means scriptsDir
+	env            []string               // Extra "KEY=value" variables for every execution
+	envAllow       []string               // Inherited variables to keep, nil keeps all
+	isolateEnv     bool                   // Do not inherit the environment of the current process
+	modePolicy     ModePolicy             // Whether scripts are made executable before running
+	retry          *RetryPolicy           // Default retry policy, nil disables retries
+	dryRun         bool                   // Resolve executions without running anything
+	hooks          []Hook                 // Called around every execution
+	fs             *fsScripts             // Source of the scripts of a runner created with NewFSRunner
+	fsMu           sync.Mutex             // Guards the materialisation of fs scripts
+	procs          chan struct{}          // Slots of the child processes allowed at once, nil means unlimited
+	limits         ResourceLimits         // Rlimits of every script, applied on Linux
+	maxOutput      int                    // Maximum bytes captured per output buffer, zero means no limit
+	redact         bool                   // Mask secrets in results
+	secrets        []string               // Secret values to mask
+	redactPatterns []*regexp.Regexp       // Extra patterns of secrets to mask
+}
 
-	dir := wd
-	if len(scriptsDir) > 0 && scriptsDir[0] != "" {
+// runnerSettings holds the settings that may change after the runner was created.
+// The map and slices are replaced, never modified, so a copy can be read without locking.
+type runnerSettings struct {
+	timeout      time.Duration
+	stdout       io.Writer
+	stderr       io.Writer
+	onLine       LineHandler
+	interpreters map[string]Interpreter
+	registered   map[string]bool
+	hooks        []Hook
+	redact       bool
+	secrets      []string
+}
+
+// NewScriptRunner creates a handler for scripts in scriptsDir.
+// If scriptsDir is not given or empty, it uses the current working directory.
+func NewScriptRunner(scriptsDir ...string) *ScriptRunner {
+	dir := ""
+	if len(scriptsDir) > 0 {
 		dir = scriptsDir[0]
 	}
+	return NewScriptRunnerWithOptions(dir)
+}
 
-	// Initialize interpreters map with default interpreters
-	interpreters := map[string]string{
-		".sh": "bash",
-		".py": "python",
+// NewScriptRunnerWithOptions creates a handler for scripts in scriptsDir configured with the
+// given options. If scriptsDir is empty, it uses the current working directory.
+func NewScriptRunnerWithOptions(scriptsDir string, opts ...RunnerOption) *ScriptRunner {
+	// Default value: scriptsDir is the current path
+	if scriptsDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			wd = "."
+		}
+		scriptsDir = wd
 	}
 
-	// Adjustment for Windows
-	if runtime.GOOS == "windows" {
-		interpreters[".sh"] = `C:\Program Files\Git\bin\bash.exe`
+	sr := &ScriptRunner{
+		scriptsDir:   scriptsDir,
+		interpreters: defaultInterpreters(),
+		precedence:   defaultPrecedence(),
 	}
 
-	return &scriptRunner{
-		scriptsDir:   dir,
-		interpreters: interpreters,
+	for _, opt := range opts {
+		opt(sr)
 	}
+
+	return sr
+}
+
+// SetTimeout sets the default timeout applied to every script execution.
+// A zero or negative duration disables the default timeout.
+func (sr *ScriptRunner) SetTimeout(timeout time.Duration) *ScriptRunner {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.timeout = timeout
+	return sr
 }
 
-// ScriptChain represents a chain of scripts to be executed in sequence
-type ScriptChain struct {
-	runner       *scriptRunner
-	scripts      []scriptExecution
-	lastExitCode int
-	lastOutput   string
-	lastError    error
+// SetOutput streams the stdout and stderr of every execution to the given writers while
+// the script runs. Either writer may be nil. The output is still captured and returned.
+func (sr *ScriptRunner) SetOutput(stdout, stderr io.Writer) *ScriptRunner {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.stdout = stdout
+	sr.stderr = stderr
+	return sr
 }
 
-// scriptExecution represents a single script execution with its arguments
-type scriptExecution struct {
-	name string
-	args []string
+// SetLineHandler registers a callback invoked for each line of output as it is produced.
+// Lines from stdout and stderr may be delivered from different goroutines.
+func (sr *ScriptRunner) SetLineHandler(onLine LineHandler) *ScriptRunner {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.onLine = onLine
+	return sr
 }
 
-// Chain creates a new script execution chain
-func (sr *scriptRunner) Chain() *ScriptChain {
-	return &ScriptChain{
-		runner:       sr,
-		scripts:      make([]scriptExecution, 0),
-		lastExitCode: 0,
-		lastOutput:   "",
-		lastError:    nil,
+// settings returns a snapshot of the settings that may change after creation
+func (sr *ScriptRunner) settings() runnerSettings {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	return runnerSettings{
+		timeout:      sr.timeout,
+		stdout:       sr.stdout,
+		stderr:       sr.stderr,
+		onLine:       sr.onLine,
+		interpreters: sr.interpreters,
+		registered:   sr.registered,
+		hooks:        sr.hooks,
+		redact:       sr.redact,
+		secrets:      sr.secrets,
 	}
 }
 
-// Then adds a script to the execution chain
-func (sc *ScriptChain) Then(scriptName string, args ...string) *ScriptChain {
-	sc.scripts = append(sc.scripts, scriptExecution{
-		name: scriptName,
-		args: args,
+// ExecScript executes a script and returns the exit code, output, and any error
+func (sr *ScriptRunner) ExecScript(scriptName string, args ...string) (int, string, error) {
+	return sr.ExecScriptContext(context.Background(), scriptName, args...)
+}
+
+// ExecScriptContext executes a script like ExecScript but stops it when ctx is done or the
+// runner's default timeout expires. On cancellation the whole process group of the script
+// is killed. A timeout is reported with an error wrapping ErrScriptTimeout.
+func (sr *ScriptRunner) ExecScriptContext(ctx context.Context, scriptName string, args ...string) (int, string, error) {
+	result := sr.Run(ctx, Command{Script: scriptName, Args: args})
+	return result.ExitCode, result.Combined, result.Err
+}
+
+// Run executes the command and returns the full result of the execution, retrying failed
+// attempts when a retry policy applies. ExecScript and ExecScriptContext are thin wrappers around Run.
+func (sr *ScriptRunner) Run(ctx context.Context, command Command) *ExecResult {
+	return sr.runWithRetry(ctx, command, sr.runOnce)
+}
+
+// runOnce executes a single attempt of the command through the registered hooks
+func (sr *ScriptRunner) runOnce(ctx context.Context, command Command) *ExecResult {
+	return sr.runHooked(ctx, command, func(ctx context.Context, command Command) *ExecResult {
+		// Hooks, such as the event log, only see redacted results
+		return sr.redactResult(command, sr.execute(ctx, command))
 	})
-	return sc
 }
 
-// Execute runs all scripts in the chain until one fails
-func (sc *ScriptChain) Execute() (int, string, error) {
-	var combinedOutput strings.Builder
+// execute runs the script of the command and captures its result
+func (sr *ScriptRunner) execute(ctx context.Context, command Command) *ExecResult {
+	scriptName := command.Script
+	args := command.Args
+	settings := sr.settings()
+
+	result := &ExecResult{
+		Script: scriptName,
+		Args:   args,
+		Dir:    sr.workDirFor(command),
+		Start:  time.Now(),
+	}
 
-	for _, script := range sc.scripts {
-		exitCode, output, err := sc.runner.ExecScript(script.name, script.args...)
-		combinedOutput.WriteString(output)
+	if sr.dryRun {
+		return sr.dryRunResult(command, result)
+	}
 
-		sc.lastExitCode = exitCode
-		sc.lastOutput = output
-		sc.lastError = err
+	scriptPath, interpreter, err := sr.resolve(scriptName)
+	result.Path = scriptPath
+	if err != nil {
+		return result.fail(err)
+	}
+	result.Interpreter = interpreter.Command
 
-		if err != nil || exitCode != 0 {
-			// Stop execution if a script fails
-			return exitCode, combinedOutput.String(), err
-		}
+	// Make the script executable if needed. The interpreter is invoked explicitly,
+	// so a failure is recorded in the result without aborting the execution.
+	result.ModeErr = sr.makeScriptsExecutable(scriptPath)
+
+	// Wait for a free process slot when the runner limits them. The wait does not count
+	// against the timeout of the script.
+	release, err := sr.acquireProc(ctx)
+	if err != nil {
+		result.End = time.Now()
+		return result.fail(fmt.Errorf("script '%s' cancelled while waiting for a process slot: %w", scriptName, err))
 	}
+	defer release()
 
-	return 0, combinedOutput.String(), nil
-}
+	if settings.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.timeout)
+		defer cancel()
+	}
 
-// ExitCode returns the exit code of the last executed script
-func (sc *ScriptChain) ExitCode() int {
-	return sc.lastExitCode
-}
+	program, cmdArgs := sr.commandLine(interpreter, scriptPath, args)
+	result.Argv = append([]string{program}, cmdArgs...)
 
-// Output returns the output of the last executed script
-func (sc *ScriptChain) Output() string {
-	return sc.lastOutput
-}
+	// Resource limits wrap the command line, Argv keeps reporting the script itself
+	program, cmdArgs = sr.limits.wrap(program, cmdArgs)
+	cmd := exec.CommandContext(ctx, program, cmdArgs...)
 
-// Error returns the error of the last executed script
-func (sc *ScriptChain) Error() error {
-	return sc.lastError
-}
+	// The working directory defaults to the directory where the scripts are located
+	cmd.Dir = result.Dir
+	cmd.Env = sr.buildEnv(command)
+	cmd.Stdin = command.stdin()
 
-// ExecScript executes a script and returns the exit code, output, and any error
-func (sr *scriptRunner) ExecScript(scriptName string, args ...string) (int, string, error) {
-
-	// Path to the main script in the scripts directory
-	scriptPath := filepath.Join(sr.scriptsDir, scriptName)
-
-	// Check if the script exists
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		// List files in the directory for debugging
-		files, _ := os.ReadDir(sr.scriptsDir)
-		fileNames := make([]string, 0, len(files))
-		for _, file := range files {
-			fileNames = append(fileNames, file.Name())
+	// Run in its own process group so cancellation also stops child processes
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+
+	// Capture stdout and stderr separately while streaming them to the live writers
+	capture := newOutputCapture(settings.onLine, sr.maxOutput)
+	if m := sr.masker(&command); m != nil {
+		capture.mask = m.mask
+	}
+
+	err = runCommand(cmd, capture.writer(StreamStdout, settings.stdout), capture.writer(StreamStderr, settings.stderr))
+	capture.flush()
+
+	result.End = time.Now()
+	result.Stdout = capture.Stdout()
+	result.Stderr = capture.Stderr()
+	result.Combined = capture.Combined()
+	result.Truncated = capture.Truncated()
+
+	// Determine the exit code and handle errors
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			result.ExitCode = -1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				result.ExitCode = exitErr.ExitCode()
+			}
+			result.Err = contextError(scriptName, ctxErr)
+			return result
 		}
-		return 1, "", fmt.Errorf("error: script '%s' does not exist. Available files: %v", scriptName, fileNames)
+
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = 1
+		}
+		result.Err = fmt.Errorf("error executing script: %w", err)
+		return result
 	}
 
-	// Get the file extension
-	ext := filepath.Ext(scriptName)
+	return result
+}
 
-	// Determine the interpreter based on the file extension
-	interpreter, supported := sr.interpreters[ext]
-	if !supported {
-		return 1, "", fmt.Errorf("unsupported script type: %s support: %v", ext, sr.interpreters)
+// runCommand runs cmd with its output copied to stdout and stderr. The pipes are created
+// here rather than by exec, so once the script exits the rest of its process group can be
+// killed at once instead of waiting for background children that still hold the output open.
+// Output held by children that escaped the group is abandoned after waitDelay.
+func runCommand(cmd *exec.Cmd, stdout, stderr io.Writer) error {
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return err
+	}
+	cmd.Stdout = outW
+	cmd.Stderr = errW
+
+	var copiers sync.WaitGroup
+	copiers.Add(2)
+	go func() {
+		defer copiers.Done()
+		io.Copy(stdout, outR)
+	}()
+	go func() {
+		defer copiers.Done()
+		io.Copy(stderr, errR)
+	}()
+
+	err = cmd.Start()
+	// The script holds its own copies of the write ends
+	outW.Close()
+	errW.Close()
+	if err == nil {
+		err = cmd.Wait()
+		killProcessGroup(cmd)
 	}
 
-	// Ensure the script is executable
-	if err := sr.makeScriptsExecutable(scriptPath); err != nil {
-		return 1, "", fmt.Errorf("error making script executable: %w", err)
+	drained := make(chan struct{})
+	go func() {
+		copiers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(waitDelay):
+		// Closing the read ends stops the copies
+		outR.Close()
+		errR.Close()
+		<-drained
 	}
+	outR.Close()
+	errR.Close()
 
-	var cmd *exec.Cmd
+	return err
+}
 
-	if runtime.GOOS == "windows" {
+// commandLine returns the program and arguments that run the script with the interpreter
+func (sr *ScriptRunner) commandLine(interpreter Interpreter, scriptPath string, args []string) (string, []string) {
+	if runtime.GOOS == "windows" && interpreter.Command == sr.settings().interpreters[".sh"].Command {
 		// Execute script with Git Bash on Windows by converting paths to Unix format
 		unixPath := strings.ReplaceAll(scriptPath, "\\", "/")
 		// Combine script path and arguments into a single quoted command string
@@ -162,47 +353,39 @@ func (sr *scriptRunner) ExecScript(scriptName string, args ...string) (int, stri
 		fullCommand := fmt.Sprintf("%q \"$@\"", unixPath)
 		cmdArgs := []string{"-c", fullCommand, "--"}
 		cmdArgs = append(cmdArgs, args...)
-		cmd = exec.Command(interpreter, cmdArgs...)
-	} else {
-		// On other operating systems, execute directly
-		cmd = exec.Command(interpreter, append([]string{scriptPath}, args...)...)
+		return interpreter.Command, append(append([]string{}, interpreter.Args...), cmdArgs...)
 	}
 
-	// Set the working directory to the directory where the scripts are located
-	cmd.Dir = sr.scriptsDir
-
-	// Configure environment variables to ensure stability
-	env := os.Environ()
-	cmd.Env = append(env, "LANG=C")
+	// On other operating systems, execute directly
+	cmdArgs := append(append([]string{}, interpreter.Args...), scriptPath)
+	return interpreter.Command, append(cmdArgs, args...)
+}
 
-	// Execute and capture output
-	output, err := cmd.CombinedOutput()
-	outputStr := string(output)
+// resolve finds the script in the search paths and returns its path and the interpreter that runs it
+func (sr *ScriptRunner) resolve(scriptName string) (string, Interpreter, error) {
+	if err := sr.materialise(scriptName); err != nil {
+		return "", Interpreter{}, err
+	}
 
-	// Determine the exit code and handle errors
+	scriptPath, err := sr.findScript(scriptName)
 	if err != nil {
-		var exitCode int
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else {
-			exitCode = 1
-		}
-		return exitCode, outputStr, fmt.Errorf("error executing script: %w", err)
+		return "", Interpreter{}, err
 	}
 
-	return 0, outputStr, nil
-}
-
-// makeScriptsExecutable makes the specified script executable if needed
-func (sr *scriptRunner) makeScriptsExecutable(scriptPath string) error {
-	// On Windows it's not necessary to make scripts executable
-	if runtime.GOOS == "windows" {
-		return nil
+	// A bare name takes the extension of the file found
+	if filepath.Ext(scriptName) == "" {
+		scriptName += filepath.Ext(scriptPath)
 	}
 
-	if err := os.Chmod(scriptPath, 0755); err != nil {
-		return fmt.Errorf("failed to make script %s executable: %w", scriptPath, err)
-	}
+	// Determine the interpreter based on the #! line or the file extension
+	interpreter, err := sr.lookupInterpreter(scriptName, scriptPath)
+	return scriptPath, interpreter, err
+}
 
-	return nil
+// contextError describes why a script was stopped by its context
+func contextError(scriptName string, ctxErr error) error {
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		return fmt.Errorf("script '%s': %w: %w", scriptName, ErrScriptTimeout, ctxErr)
+	}
+	return fmt.Errorf("script '%s' cancelled: %w", scriptName, ctxErr)
 }
@@ -1,10 +1,13 @@
 package devscripts
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewScriptRunner(t *testing.T) {
@@ -180,3 +183,83 @@ exit 0
 		t.Errorf("Expected output to contain script 2 output, got: %q", chain.Output())
 	}
 }
+
+func TestExecScriptContextTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// The script spawns a child that would outlive the parent if the group was not killed
+	err := os.WriteFile(filepath.Join(tempDir, "slow.sh"), []byte(`#!/bin/bash
+echo "started"
+sleep 30 &
+wait
+`), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	// Test runner default timeout
+	runner := NewScriptRunner(tempDir).SetTimeout(200 * time.Millisecond)
+
+	start := time.Now()
+	_, output, err := runner.ExecScript("slow.sh")
+	if !errors.Is(err, ErrScriptTimeout) {
+		t.Fatalf("Expected ErrScriptTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected script to be stopped quickly, took %v", elapsed)
+	}
+	if !strings.Contains(output, "started") {
+		t.Errorf("Expected partial output to be returned, got %q", output)
+	}
+
+	// Test cancellation through the context
+	runner = NewScriptRunner(tempDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	_, _, err = runner.ExecScriptContext(ctx, "slow.sh")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if errors.Is(err, ErrScriptTimeout) {
+		t.Errorf("Cancellation should not be reported as timeout: %v", err)
+	}
+}
+
+func TestScriptChainExecuteContext(t *testing.T) {
+	tempDir := t.TempDir()
+
+	scripts := map[string]string{
+		"fast.sh": "#!/bin/bash\necho \"fast\"\n",
+		"slow.sh": "#!/bin/bash\necho \"slow\"\nsleep 30\n",
+		"last.sh": "#!/bin/bash\necho \"last\"\n",
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	runner := NewScriptRunner(tempDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	chain := runner.Chain().
+		Then("fast.sh").
+		Then("slow.sh").
+		Then("last.sh")
+
+	_, output, err := chain.ExecuteContext(ctx)
+	if !errors.Is(err, ErrScriptTimeout) {
+		t.Fatalf("Expected ErrScriptTimeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "slow.sh") {
+		t.Errorf("Expected error to name the step that timed out, got %v", err)
+	}
+	if !strings.Contains(output, "fast") {
+		t.Errorf("Output missing fast.sh results: %q", output)
+	}
+	if strings.Contains(output, "last") {
+		t.Error("last.sh should not have executed after the timeout")
+	}
+}
@@ -0,0 +1,160 @@
+package devscripts
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// OutputStream identifies the stream a script wrote a line of output to
+type OutputStream string
+
+const (
+	StreamStdout OutputStream = "stdout"
+	StreamStderr OutputStream = "stderr"
+)
+
+// LineHandler is called for every complete line a script writes, as soon as it is written
+type LineHandler func(stream OutputStream, line string)
+
+// outputCapture collects the stdout and stderr of a script separately and merged,
+// while forwarding the data to optional live writers and a line handler.
+type outputCapture struct {
+	mu       sync.Mutex
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	combined bytes.Buffer
+	onLine   LineHandler
+	mask     func(string) string // Masks secrets in the lines sent to live writers and onLine, nil for none
+	limit    int                 // Maximum bytes kept per buffer, zero means no limit
+	truncate bool                // Some output was dropped because of the limit
+	writers  []*streamWriter
+}
+
+// newOutputCapture creates a capture that calls onLine (if not nil) for each line and keeps
+// at most limit bytes per buffer (no limit if zero)
+func newOutputCapture(onLine LineHandler, limit int) *outputCapture {
+	return &outputCapture{onLine: onLine, limit: limit}
+}
+
+// writer returns the io.Writer to attach to the given stream of a command.
+// live receives the output as it arrives, line by line when masking, and may be nil.
+func (oc *outputCapture) writer(stream OutputStream, live io.Writer) io.Writer {
+	sw := &streamWriter{capture: oc, stream: stream, live: live}
+	oc.writers = append(oc.writers, sw)
+	return sw
+}
+
+// flush delivers any trailing output that did not end with a newline to the line handler
+func (oc *outputCapture) flush() {
+	for _, sw := range oc.writers {
+		sw.flush()
+	}
+}
+
+// Stdout returns the captured standard output
+func (oc *outputCapture) Stdout() string {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	return oc.stdout.String()
+}
+
+// Stderr returns the captured standard error
+func (oc *outputCapture) Stderr() string {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	return oc.stderr.String()
+}
+
+// Combined returns stdout and stderr merged in the order the chunks were received
+func (oc *outputCapture) Combined() string {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	return oc.combined.String()
+}
+
+// Truncated reports whether output was dropped because of the limit
+func (oc *outputCapture) Truncated() bool {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	return oc.truncate
+}
+
+// keep writes p to buf up to the limit of the capture
+func (oc *outputCapture) keep(buf *bytes.Buffer, p []byte) {
+	if oc.limit > 0 && buf.Len()+len(p) > oc.limit {
+		p = p[:max(oc.limit-buf.Len(), 0)]
+		oc.truncate = true
+	}
+	buf.Write(p)
+}
+
+// streamWriter is the io.Writer attached to one stream of a command
+type streamWriter struct {
+	capture *outputCapture
+	stream  OutputStream
+	live    io.Writer
+	partial []byte // Incomplete last line waiting for its newline
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	oc := sw.capture
+
+	oc.mu.Lock()
+	if sw.stream == StreamStderr {
+		oc.keep(&oc.stderr, p)
+	} else {
+		oc.keep(&oc.stdout, p)
+	}
+	oc.keep(&oc.combined, p)
+	oc.mu.Unlock()
+
+	if sw.live != nil && oc.mask == nil {
+		// A failing live writer must not break the script execution
+		sw.live.Write(p)
+	}
+
+	if oc.onLine != nil || (sw.live != nil && oc.mask != nil) {
+		sw.partial = append(sw.partial, p...)
+		for {
+			i := bytes.IndexByte(sw.partial, '\n')
+			if i < 0 {
+				break
+			}
+			line := sw.partial[:i+1]
+			sw.partial = sw.partial[i+1:]
+			sw.emit(line)
+		}
+
+		// Without newlines the pending line would grow past the limit
+		if oc.limit > 0 && len(sw.partial) > oc.limit {
+			sw.flush()
+		}
+	}
+
+	return len(p), nil
+}
+
+// emit delivers a line, with its newline if it has one, masked to the live writer and to
+// the line handler without the newline
+func (sw *streamWriter) emit(line []byte) {
+	oc := sw.capture
+	text := string(line)
+	if oc.mask != nil {
+		text = oc.mask(text)
+		if sw.live != nil {
+			sw.live.Write([]byte(text))
+		}
+	}
+	if oc.onLine != nil {
+		oc.onLine(sw.stream, strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r"))
+	}
+}
+
+func (sw *streamWriter) flush() {
+	if len(sw.partial) > 0 {
+		sw.emit(sw.partial)
+		sw.partial = nil
+	}
+}
@@ -0,0 +1,64 @@
+package devscripts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExecScriptStreamingOutput(t *testing.T) {
+	tempDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tempDir, "stream.sh"), []byte(`#!/bin/bash
+echo "out line 1"
+echo "err line 1" >&2
+echo "out line 2"
+printf "no newline"
+`), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	var stdout, stderr strings.Builder
+	var mu sync.Mutex
+	lines := map[OutputStream][]string{}
+
+	runner := NewScriptRunner(tempDir).
+		SetOutput(&stdout, &stderr).
+		SetLineHandler(func(stream OutputStream, line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines[stream] = append(lines[stream], line)
+		})
+
+	exitCode, output, err := runner.ExecScript("stream.sh")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("Expected success, got exit code %d and error %v", exitCode, err)
+	}
+
+	// The live writers receive each stream separately
+	if stdout.String() != "out line 1\nout line 2\nno newline" {
+		t.Errorf("Unexpected streamed stdout: %q", stdout.String())
+	}
+	if stderr.String() != "err line 1\n" {
+		t.Errorf("Unexpected streamed stderr: %q", stderr.String())
+	}
+
+	// The returned output still contains both streams
+	for _, want := range []string{"out line 1", "err line 1", "out line 2", "no newline"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, output)
+		}
+	}
+
+	// The line handler receives complete lines, including the trailing one
+	wantStdout := []string{"out line 1", "out line 2", "no newline"}
+	if strings.Join(lines[StreamStdout], "|") != strings.Join(wantStdout, "|") {
+		t.Errorf("Expected stdout lines %v, got %v", wantStdout, lines[StreamStdout])
+	}
+	if len(lines[StreamStderr]) != 1 || lines[StreamStderr][0] != "err line 1" {
+		t.Errorf("Expected stderr lines [err line 1], got %v", lines[StreamStderr])
+	}
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package devscripts
+
+import "os/exec"
+
+// setProcessGroup keeps the default behaviour on Windows, where cancellation
+// kills the direct child process.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup does nothing on Windows, where scripts do not get their own process group
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -1,6 +1,7 @@
 package devscripts
 
 import (
+	"io/fs"
 	"os"
 	"strings"
 
@@ -21,6 +22,12 @@ func NewDevScriptsReadmeUpdater(scriptsDir string) *DevScriptsReadmeUpdater {
 	}
 }
 
+// NewDevScriptsReadmeUpdaterFS creates a DevScriptsReadmeUpdater documenting the scripts
+// at the root of fsys, e.g. EmbeddedScripts
+func NewDevScriptsReadmeUpdaterFS(fsys fs.FS) *DevScriptsReadmeUpdater {
+	return &DevScriptsReadmeUpdater{parser: NewScriptParserFS(fsys)}
+}
+
 // GenerateScriptsSection generates a markdown section for README with scripts table
 func (dru *DevScriptsReadmeUpdater) GenerateScriptsSection() (string, error) {
 	scripts, err := dru.parser.ParseScripts()
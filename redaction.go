@@ -0,0 +1,208 @@
+package devscripts
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// minEnvSecretLength is the shortest value of a secret-named variable that is masked, so
+// values like "1" or "true" do not blank out unrelated output
+const minEnvSecretLength = 6
+
+// defaultRedactPatterns match common secrets. A first capture group is kept, e.g. the
+// name of the variable, and the rest of the match is masked.
+var defaultRedactPatterns = []*regexp.Regexp{
+	// GitHub personal access, OAuth, user-to-server, server-to-server and refresh tokens
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	// Fine-grained GitHub personal access tokens
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	// GH_TOKEN=value, GITHUB_TOKEN: value
+	regexp.MustCompile(`(\b(?:GH|GITHUB)_TOKEN\s*[=:]\s*)\S+`),
+	// SSH and other PEM private key blocks
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// WithRedaction masks the given secret values and common token patterns (GitHub tokens,
+// GH_TOKEN assignments, private key blocks) in the results of every execution: output,
+// errors and arguments, so hooks such as the event log and chain outputs only see masked
+// values. Values of secret-named variables in the script environment are masked too.
+// Live writers and line handlers receive the output line by line with every line masked,
+// so secrets spanning several lines, such as private key blocks, are only masked in results.
+func WithRedaction(secrets ...string) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.AddSecrets(secrets...)
+	}
+}
+
+// WithRedactPatterns masks the matches of the patterns in addition to the default ones and
+// enables redaction. A first capture group is kept.
+func WithRedactPatterns(patterns ...*regexp.Regexp) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.AddSecrets()
+		sr.redactPatterns = append(sr.redactPatterns, patterns...)
+	}
+}
+
+// AddSecrets registers secret values to mask, e.g. a token read at runtime, and enables redaction
+func (sr *ScriptRunner) AddSecrets(secrets ...string) *ScriptRunner {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.redact = true
+	for _, secret := range secrets {
+		if secret != "" {
+			sr.secrets = append(slices.Clip(sr.secrets), secret)
+		}
+	}
+	return sr
+}
+
+// Redact masks the secrets known to the runner in s. It returns s unchanged when redaction
+// is not enabled.
+func (sr *ScriptRunner) Redact(s string) string {
+	return sr.masker(nil).mask(s)
+}
+
+// secretMasker replaces secret values and pattern matches with redactedValue
+type secretMasker struct {
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// masker returns the masker of an execution of the command, nil when redaction is disabled.
+// Without a command only the registered secrets are masked.
+func (sr *ScriptRunner) masker(command *Command) *secretMasker {
+	settings := sr.settings()
+	if !settings.redact {
+		return nil
+	}
+
+	values := slices.Clone(settings.secrets)
+	if command != nil {
+		for _, kv := range sr.buildEnv(*command) {
+			name, value, _ := strings.Cut(kv, "=")
+			if len(value) >= minEnvSecretLength && secretName.MatchString(name) {
+				values = append(values, value)
+			}
+		}
+	}
+
+	// Longer values first, so a secret containing another one is masked whole
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+
+	return &secretMasker{
+		values:   values,
+		patterns: append(slices.Clone(defaultRedactPatterns), sr.redactPatterns...),
+	}
+}
+
+// mask returns s with every secret replaced
+func (m *secretMasker) mask(s string) string {
+	if m == nil || s == "" {
+		return s
+	}
+	for _, value := range m.values {
+		s = strings.ReplaceAll(s, value, redactedValue)
+	}
+	for _, pattern := range m.patterns {
+		s = pattern.ReplaceAllString(s, "${1}"+redactedValue)
+	}
+	return s
+}
+
+// maskAll returns a copy of values with every secret replaced
+func (m *secretMasker) maskAll(values []string) []string {
+	if m == nil || values == nil {
+		return values
+	}
+	masked := make([]string, len(values))
+	for i, value := range values {
+		masked[i] = m.mask(value)
+	}
+	return masked
+}
+
+// redactResult masks the secrets in the output, error and arguments of the result
+func (sr *ScriptRunner) redactResult(command Command, result *ExecResult) *ExecResult {
+	m := sr.masker(&command)
+	if m == nil {
+		return result
+	}
+
+	result.Args = m.maskAll(result.Args)
+	result.Argv = m.maskAll(result.Argv)
+	result.Stdout = m.mask(result.Stdout)
+	result.Stderr = m.mask(result.Stderr)
+	result.Combined = m.mask(result.Combined)
+	result.Err = m.maskErr(result.Err)
+	return result
+}
+
+// maskPlan returns a copy of the plan with every secret replaced, for hooks
+func (m *secretMasker) maskPlan(plan PlanStep) PlanStep {
+	if m == nil {
+		return plan
+	}
+	plan.Script = m.mask(plan.Script)
+	plan.Path = m.mask(plan.Path)
+	plan.Argv = m.maskAll(plan.Argv)
+	plan.EnvSet = m.maskAll(plan.EnvSet)
+	plan.Err = m.maskErr(plan.Err)
+	return plan
+}
+
+// maskErr returns err with the secrets hidden in its message and typed fields
+func (m *secretMasker) maskErr(err error) error {
+	if m == nil || err == nil {
+		return err
+	}
+	if msg := m.mask(err.Error()); msg != err.Error() {
+		return &redactedError{msg: msg, err: err, masker: m}
+	}
+	return err
+}
+
+// redactedError hides secrets in the message of an error while keeping it inspectable with
+// errors.Is and errors.As. The typed errors of the package are matched as masked copies.
+type redactedError struct {
+	msg    string
+	err    error
+	masker *secretMasker
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+func (e *redactedError) As(target any) bool {
+	switch target := target.(type) {
+	case **ScriptNotFoundError:
+		var raw *ScriptNotFoundError
+		if !errors.As(e.err, &raw) {
+			return false
+		}
+		*target = &ScriptNotFoundError{
+			Script:      e.masker.mask(raw.Script),
+			Dirs:        e.masker.maskAll(raw.Dirs),
+			Suggestions: e.masker.maskAll(raw.Suggestions),
+		}
+		return true
+	case **UnsupportedScriptError:
+		var raw *UnsupportedScriptError
+		if !errors.As(e.err, &raw) {
+			return false
+		}
+		*target = &UnsupportedScriptError{
+			Script:     e.masker.mask(raw.Script),
+			Ext:        e.masker.mask(raw.Ext),
+			Registered: raw.Registered,
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package devscripts
+
+import "time"
+
+// ResourceLimits are the rlimits applied to every script on Linux. They are ignored on
+// other systems. Zero values are not limited.
+type ResourceLimits struct {
+	CPUTime   time.Duration // CPU time (RLIMIT_CPU), rounded up to whole seconds
+	Memory    int64         // Virtual memory in bytes (RLIMIT_AS)
+	OpenFiles int           // Open file descriptors (RLIMIT_NOFILE)
+}
+
+// WithResourceLimits applies the limits to every script the runner starts, and to its children
+func WithResourceLimits(limits ResourceLimits) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.limits = limits
+	}
+}
+
+// WithMaxOutput caps the stdout, stderr and combined output captured per execution to n
+// bytes, so a script that floods its output cannot exhaust memory. Live writers still get
+// everything. Zero or a negative value means no cap.
+func WithMaxOutput(n int) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.maxOutput = max(n, 0)
+	}
+}
+
+// isZero reports whether no limit is set
+func (l ResourceLimits) isZero() bool {
+	return l.CPUTime <= 0 && l.Memory <= 0 && l.OpenFiles <= 0
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+package devscripts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// wrap returns the command line that sets the limits with ulimit before running the program,
+// so they apply from the first instruction of the script
+func (l ResourceLimits) wrap(program string, args []string) (string, []string) {
+	if l.isZero() {
+		return program, args
+	}
+
+	var ulimits []string
+	if l.CPUTime > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -t %d", (l.CPUTime+time.Second-1)/time.Second))
+	}
+	if l.Memory > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", (l.Memory+1023)/1024))
+	}
+	if l.OpenFiles > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -n %d", l.OpenFiles))
+	}
+
+	script := strings.Join(ulimits, " && ") + ` && exec "$0" "$@"`
+	return "/bin/sh", append([]string{"-c", script, program}, args...)
+}
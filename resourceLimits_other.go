@@ -0,0 +1,8 @@
+//go:build !linux
+
+package devscripts
+
+// wrap returns the command line unchanged: resource limits are only applied on Linux
+func (l ResourceLimits) wrap(program string, args []string) (string, []string) {
+	return program, args
+}
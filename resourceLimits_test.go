@@ -0,0 +1,92 @@
+package devscripts
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessGroupCleanup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts do not get their own process group on Windows")
+	}
+
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		// The background child keeps stdout open after the script exits
+		"orphan.sh": "#!/bin/bash\nsleep 30 &\necho $! > child.pid\necho started\n",
+		// A killed child may stay a zombie until init reaps it
+		"alive.sh": "#!/bin/bash\nstate=$(ps -o stat= -p $(cat child.pid))\n[[ -z $state || $state == Z* ]] && echo gone || echo alive\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	start := time.Now()
+	code, output, err := runner.ExecScript("orphan.sh")
+	if err != nil || code != 0 || output != "started\n" {
+		t.Fatalf("Expected orphan.sh to succeed, got %d %q %v", code, output, err)
+	}
+	if elapsed := time.Since(start); elapsed >= waitDelay {
+		t.Errorf("Expected not to wait for the background child, took %v", elapsed)
+	}
+
+	if _, output, _ := runner.ExecScript("alive.sh"); output != "gone\n" {
+		t.Errorf("Expected the background child to be killed, it is %s", output)
+	}
+}
+
+func TestResourceLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only applied on Linux")
+	}
+
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"limits.sh": "#!/bin/bash\necho \"$(ulimit -t) $(ulimit -v) $(ulimit -n) $*\"\n",
+	})
+
+	runner := NewScriptRunnerWithOptions(tempDir, WithResourceLimits(ResourceLimits{
+		CPUTime:   1500 * time.Millisecond,
+		Memory:    1 << 30,
+		OpenFiles: 64,
+	}))
+
+	result := runner.Run(t.Context(), Command{Script: "limits.sh", Args: []string{"a b", "c"}})
+	if !result.Success() || result.Stdout != "2 1048576 64 a b c\n" {
+		t.Errorf("Expected the limits to apply, got %q %v", result.Stdout, result.Err)
+	}
+	if result.Argv[0] != shInterpreter("#!/bin/bash") || result.Argv[1] != filepath.Join(tempDir, "limits.sh") {
+		t.Errorf("Expected Argv to report the script command line, got %v", result.Argv)
+	}
+}
+
+func TestMaxOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"flood.sh": "#!/bin/bash\nfor i in $(seq 1 1000); do echo \"line $i\"; done\necho done >&2\n",
+	})
+
+	var live bytes.Buffer
+	runner := NewScriptRunnerWithOptions(tempDir, WithMaxOutput(100), WithOutput(&live, nil))
+
+	result := runner.Run(t.Context(), Command{Script: "flood.sh"})
+	if !result.Success() || !result.Truncated {
+		t.Fatalf("Expected a truncated successful result, got %v truncated=%v", result.Err, result.Truncated)
+	}
+	if len(result.Stdout) != 100 || len(result.Combined) != 100 || !strings.HasPrefix(result.Stdout, "line 1\n") {
+		t.Errorf("Expected output capped to 100 bytes, got %d %d", len(result.Stdout), len(result.Combined))
+	}
+	if result.Stderr != "done\n" {
+		t.Errorf("Expected stderr to be capped separately, got %q", result.Stderr)
+	}
+	if !strings.HasSuffix(live.String(), "line 1000\n") {
+		t.Errorf("Expected the live writer to get the whole output")
+	}
+
+	if result := NewScriptRunner(tempDir).Run(t.Context(), Command{Script: "flood.sh"}); result.Truncated {
+		t.Errorf("Expected no cap by default")
+	}
+}
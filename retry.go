@@ -0,0 +1,110 @@
+package devscripts
+
+import (
+	"context"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// RetryPolicy retries failed executions of flaky scripts, e.g. the ones calling gh or git push
+type RetryPolicy struct {
+	MaxAttempts int                    // Total attempts including the first one, below 2 disables retries
+	Backoff     time.Duration          // Delay before the second attempt, doubled after every attempt
+	MaxBackoff  time.Duration          // Upper bound of the delay, zero for none
+	Jitter      float64                // Fraction of the delay (0 to 1) randomly added or removed
+	RetryIf     func(*ExecResult) bool // Decides whether a failed attempt is retried, nil retries every failure
+}
+
+// RetryOnExitCodes retries only attempts that exited with one of the given codes
+func RetryOnExitCodes(codes ...int) func(*ExecResult) bool {
+	return func(result *ExecResult) bool {
+		return slices.Contains(codes, result.ExitCode)
+	}
+}
+
+// RetryOnOutput retries only attempts whose combined output matches the regular expression.
+// It panics if the pattern does not compile.
+func RetryOnOutput(pattern string) func(*ExecResult) bool {
+	re := regexp.MustCompile(pattern)
+	return func(result *ExecResult) bool {
+		return re.MatchString(result.Combined)
+	}
+}
+
+// WithRetry sets the default retry policy of every execution. Commands with their own
+// Retry policy use that one instead.
+func WithRetry(policy RetryPolicy) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.retry = &policy
+	}
+}
+
+// Retry sets the retry policy of every command of the last added step
+func (sc *ScriptChain) Retry(policy RetryPolicy) *ScriptChain {
+	if step := sc.lastStep(); step != nil {
+		for i := range step.commands {
+			step.commands[i].Retry = &policy
+		}
+	}
+	return sc
+}
+
+// shouldRetry reports whether the failed attempt number attempt (starting at 1) is retried
+func (p *RetryPolicy) shouldRetry(ctx context.Context, result *ExecResult, attempt int) bool {
+	if p == nil || result.Success() || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if result.Argv == nil {
+		// The script never started (missing file, unsupported type), retrying will not help
+		return false
+	}
+	return p.RetryIf == nil || p.RetryIf(result)
+}
+
+// delay returns the wait before attempt number attempt+1
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff << (attempt - 1)
+	if d < 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		jitter := float64(d) * p.Jitter * (2*rand.Float64() - 1)
+		d += time.Duration(jitter)
+	}
+	return max(d, 0)
+}
+
+// runWithRetry runs the command, retrying failed attempts according to its policy.
+// The earlier attempts are kept in the Attempts field of the returned result.
+func (sr *ScriptRunner) runWithRetry(ctx context.Context, command Command, runOnce func(context.Context, Command) *ExecResult) *ExecResult {
+	policy := command.Retry
+	if policy == nil {
+		policy = sr.retry
+	}
+
+	var attempts []*ExecResult
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && command.Input != "" {
+			// Canned answers can be replayed, readers are consumed by the first attempt
+			command.Stdin = nil
+		}
+
+		result := runOnce(ctx, command)
+		if !policy.shouldRetry(ctx, result, attempt) {
+			result.Attempts = attempts
+			return result
+		}
+		attempts = append(attempts, result)
+
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			result.Attempts = attempts[:len(attempts)-1]
+			return result
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package devscripts
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyScript fails with the given exit code and message until it ran succeedOn times
+const flakyScript = `#!/bin/bash
+count=$(( $(cat "count-$1" 2>/dev/null || echo 0) + 1 ))
+echo "$count" > "count-$1"
+if (( count < $2 )); then
+  echo "attempt $count: $4"
+  exit $3
+fi
+echo "attempt $count: ok"
+`
+
+func TestRunRetry(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{"flaky.sh": flakyScript})
+
+	runner := NewScriptRunner(tempDir)
+	policy := &RetryPolicy{MaxAttempts: 4, Backoff: 10 * time.Millisecond, Jitter: 0.5}
+
+	// Succeeds on the third attempt
+	result := runner.Run(context.Background(), Command{Script: "flaky.sh", Args: []string{"a", "3", "1", "network"}, Retry: policy})
+	if !result.Success() || result.AttemptCount() != 3 {
+		t.Fatalf("Expected success after 3 attempts, got %v after %d", result.Err, result.AttemptCount())
+	}
+	for i, attempt := range result.Attempts {
+		if attempt.ExitCode != 1 || !strings.Contains(attempt.Stdout, "network") {
+			t.Errorf("Attempt %d: unexpected result %d %q", i+1, attempt.ExitCode, attempt.Stdout)
+		}
+	}
+
+	// Gives up after MaxAttempts
+	result = runner.Run(context.Background(), Command{Script: "flaky.sh", Args: []string{"b", "10", "1", "network"}, Retry: policy})
+	if result.Success() || result.AttemptCount() != 4 {
+		t.Errorf("Expected failure after 4 attempts, got %d", result.AttemptCount())
+	}
+
+	// The predicate decides which failures are retried
+	exitCodePolicy := &RetryPolicy{MaxAttempts: 3, RetryIf: RetryOnExitCodes(128)}
+	result = runner.Run(context.Background(), Command{Script: "flaky.sh", Args: []string{"c", "3", "2", "usage"}, Retry: exitCodePolicy})
+	if result.AttemptCount() != 1 {
+		t.Errorf("Expected exit code 2 not to be retried, got %d attempts", result.AttemptCount())
+	}
+
+	outputPolicy := &RetryPolicy{MaxAttempts: 3, RetryIf: RetryOnOutput(`(?i)could not resolve host`)}
+	result = runner.Run(context.Background(), Command{Script: "flaky.sh", Args: []string{"d", "2", "1", "Could not resolve host"}, Retry: outputPolicy})
+	if !result.Success() || result.AttemptCount() != 2 {
+		t.Errorf("Expected output match to be retried, got %v after %d", result.Err, result.AttemptCount())
+	}
+
+	// Scripts that never started are not retried
+	result = runner.Run(context.Background(), Command{Script: "missing.sh", Retry: policy})
+	if result.AttemptCount() != 0 || len(result.Attempts) != 0 {
+		t.Errorf("Expected missing script not to be retried, got %d attempts", len(result.Attempts))
+	}
+}
+
+func TestRunRetryDefaultsAndChain(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{"flaky.sh": flakyScript})
+
+	// Runner default policy applies to ExecScript
+	runner := NewScriptRunnerWithOptions(tempDir, WithRetry(RetryPolicy{MaxAttempts: 2}))
+	exitCode, output, err := runner.ExecScript("flaky.sh", "a", "2", "1", "flaky")
+	if exitCode != 0 || err != nil || !strings.Contains(output, "attempt 2: ok") {
+		t.Errorf("Expected default retry to succeed, got %d %v %q", exitCode, err, output)
+	}
+
+	// Chain steps retry with their own policy
+	chain := NewScriptRunner(tempDir).Chain().
+		Then("flaky.sh", "b", "3", "1", "flaky").Retry(RetryPolicy{MaxAttempts: 3}).
+		Then("flaky.sh", "c", "1", "0", "")
+	if _, _, err := chain.Execute(); err != nil {
+		t.Fatalf("Expected chain to succeed with retries, got %v", err)
+	}
+	if attempts := chain.Results()[0].AttemptCount(); attempts != 3 {
+		t.Errorf("Expected 3 attempts of the first step, got %d", attempts)
+	}
+
+	// Cancellation stops waiting for the next attempt
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result := runner.Run(ctx, Command{Script: "flaky.sh", Args: []string{"d", "10", "1", "flaky"}, Retry: &RetryPolicy{MaxAttempts: 5, Backoff: time.Minute}})
+	if time.Since(start) > 5*time.Second || result.AttemptCount() != 1 {
+		t.Errorf("Expected cancellation during backoff to stop retrying, got %d attempts in %v", result.AttemptCount(), time.Since(start))
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := policy.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 50 {
+		if got := policy.delay(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Expected jittered delay within 50%% of 1s, got %v", got)
+		}
+	}
+}
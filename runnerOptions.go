@@ -0,0 +1,37 @@
+package devscripts
+
+import (
+	"io"
+	"time"
+)
+
+// RunnerOption configures a runner created with NewScriptRunner
+type RunnerOption func(*ScriptRunner)
+
+// WithInterpreter registers the interpreter used for scripts with the given extension
+func WithInterpreter(ext, command string, args ...string) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.RegisterInterpreter(ext, command, args...)
+	}
+}
+
+// WithTimeout sets the default timeout applied to every script execution
+func WithTimeout(timeout time.Duration) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.SetTimeout(timeout)
+	}
+}
+
+// WithOutput streams the stdout and stderr of every execution to the given writers
+func WithOutput(stdout, stderr io.Writer) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.SetOutput(stdout, stderr)
+	}
+}
+
+// WithLineHandler registers a callback invoked for each line of output
+func WithLineHandler(onLine LineHandler) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.SetLineHandler(onLine)
+	}
+}
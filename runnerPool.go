@@ -0,0 +1,72 @@
+package devscripts
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// WithMaxProcesses limits the number of child processes the runner starts at once.
+// Further executions wait for a free slot. Zero or a negative value means no limit.
+func WithMaxProcesses(n int) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.procs = nil
+		if n > 0 {
+			sr.procs = make(chan struct{}, n)
+		}
+	}
+}
+
+// MaxProcesses returns the number of child processes the runner starts at once, 0 when unlimited
+func (sr *ScriptRunner) MaxProcesses() int {
+	return cap(sr.procs)
+}
+
+// acquireProc waits for a free process slot and returns the function that releases it.
+// It fails when ctx is done first.
+func (sr *ScriptRunner) acquireProc(ctx context.Context) (func(), error) {
+	if sr.procs == nil {
+		return func() {}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case sr.procs <- struct{}{}:
+		return func() { <-sr.procs }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RunBatch runs the command once per argument set, appending each set to command.Args,
+// e.g. one gitpush.sh per repository. Executions run concurrently, bounded by the runner
+// process limit or the number of CPUs, and the results are returned in input order.
+// A Command.Stdin reader would be shared by every execution; use Input instead.
+func (sr *ScriptRunner) RunBatch(ctx context.Context, command Command, argSets [][]string) []*ExecResult {
+	workers := sr.MaxProcesses()
+	if workers == 0 {
+		workers = defaultWorkers()
+	}
+
+	results := make([]*ExecResult, len(argSets))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, args := range argSets {
+		item := command
+		item.Args = append(slices.Clip(command.Args), args...)
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = sr.Run(ctx, item)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
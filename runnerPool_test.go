@@ -0,0 +1,145 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunBatch(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		// Later items finish first, so completion order differs from input order
+		"repo.sh": "#!/bin/bash\nsleep $(( 3 - $2 ))e-1\necho \"$1 $2 $3\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+	results := runner.RunBatch(context.Background(), Command{Script: "repo.sh", Args: []string{"push"}},
+		[][]string{{"0", "a"}, {"1", "b"}, {"2", "c"}})
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	for i, result := range results {
+		want := fmt.Sprintf("push %d %c\n", i, 'a'+i)
+		if !result.Success() || result.Stdout != want {
+			t.Errorf("Result %d: expected %q, got %q (%v)", i, want, result.Stdout, result.Err)
+		}
+	}
+}
+
+func TestMaxProcesses(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"span.sh": "#!/bin/bash\ndate +%s%N\nsleep 0.1\ndate +%s%N\n",
+	})
+
+	runner := NewScriptRunnerWithOptions(tempDir, WithMaxProcesses(1))
+	if runner.MaxProcesses() != 1 {
+		t.Fatalf("Expected a limit of 1, got %d", runner.MaxProcesses())
+	}
+
+	results := runner.RunBatch(context.Background(), Command{Script: "span.sh"}, [][]string{{}, {}, {}})
+
+	type span struct{ start, end int64 }
+	var spans []span
+	for _, result := range results {
+		fields := strings.Fields(result.Stdout)
+		if len(fields) != 2 {
+			t.Fatalf("Unexpected output %q (%v)", result.Stdout, result.Err)
+		}
+		start, _ := strconv.ParseInt(fields[0], 10, 64)
+		end, _ := strconv.ParseInt(fields[1], 10, 64)
+		spans = append(spans, span{start, end})
+	}
+
+	for i := range spans {
+		for j := range spans {
+			if i != j && spans[i].start < spans[j].end && spans[j].start < spans[i].end {
+				t.Errorf("Processes %d and %d overlapped despite a limit of 1", i, j)
+			}
+		}
+	}
+
+	// Waiting for a slot stops when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := runner.Run(ctx, Command{Script: "span.sh"}); result.Err == nil || result.Argv != nil {
+		t.Errorf("Expected a cancelled run not to start, got %+v", result)
+	}
+}
+
+func TestMaxProcessesTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"slow.sh": "#!/bin/bash\nsleep 0.6\necho done\n",
+	})
+
+	// Time spent waiting for a slot does not count against the timeout
+	runner := NewScriptRunnerWithOptions(tempDir, WithMaxProcesses(1), WithTimeout(time.Second))
+	for i, result := range runner.RunBatch(context.Background(), Command{Script: "slow.sh"}, [][]string{{}, {}, {}}) {
+		if !result.Success() {
+			t.Errorf("Run %d: expected success despite queueing, got %v", i, result.Err)
+		}
+	}
+
+	// A queue wait cut short is a cancellation, not a script timeout
+	busy := make(chan struct{})
+	go func() {
+		defer close(busy)
+		runner.Run(context.Background(), Command{Script: "slow.sh"})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	result := runner.Run(ctx, Command{Script: "slow.sh"})
+	if result.Argv != nil || errors.Is(result.Err, ErrScriptTimeout) || !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected a cancelled queue wait, got argv=%v err=%v", result.Argv, result.Err)
+	}
+	<-busy
+}
+
+func TestConcurrentUse(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"echo.sh": "#!/bin/bash\necho \"$1\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+	chain := runner.Chain().Then("echo.sh", "one").Then("echo.sh", "two")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch i % 4 {
+			case 0:
+				runner.SetOutput(io.Discard, io.Discard).SetTimeout(0)
+			case 1:
+				runner.RegisterInterpreter(".zsh", "zsh")
+				runner.Use(HookFuncs{})
+			case 2:
+				if _, output, err := chain.Execute(); err != nil || output != "one\ntwo\n" {
+					t.Errorf("Unexpected chain result %q %v", output, err)
+				}
+			default:
+				if _, output, err := runner.ExecScript("echo.sh", strconv.Itoa(i)); err != nil || output != strconv.Itoa(i)+"\n" {
+					t.Errorf("Unexpected result %q %v", output, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(chain.Results()) != 2 {
+		t.Errorf("Expected the chain to keep the results of a complete run, got %d", len(chain.Results()))
+	}
+}
@@ -0,0 +1,419 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStepSkipped is returned (wrapped) in the result of a chain step that did not run
+var ErrStepSkipped = errors.New("step skipped")
+
+// GroupPolicy decides how a parallel group reacts when one of its steps fails
+type GroupPolicy int
+
+const (
+	// FailFast stops the running steps of the group and skips the pending ones
+	FailFast GroupPolicy = iota
+	// CollectAll lets every step of the group run and reports all failures
+	CollectAll
+)
+
+// ScriptChain represents a chain of scripts to be executed in sequence
+type ScriptChain struct {
+	runner     Runner
+	name       string
+	journalDir string // Directory of the journal file, empty disables the journal
+	steps      []*chainStep
+	mu         sync.Mutex // Guards results, so a built chain can be run from several goroutines
+	results    []*ExecResult
+}
+
+// stepKind decides when a chain step runs
+type stepKind int
+
+const (
+	stepNormal    stepKind = iota // Runs while no earlier step failed
+	stepFinally                   // Always runs
+	stepOnFailure                 // Runs only when an earlier step failed
+)
+
+// chainStep is a single command or a group of commands run concurrently
+type chainStep struct {
+	commands     []Command
+	parallel     bool
+	workers      int         // Maximum concurrent commands of a parallel group
+	policy       GroupPolicy // Failure policy of a parallel group
+	kind         stepKind
+	allowFailure bool     // A failure is recorded but does not stop the chain
+	outputs      []string // Names of the outputs the step sets
+}
+
+// NewCommand creates a command for the given script and arguments
+func NewCommand(scriptName string, args ...string) Command {
+	return Command{Script: scriptName, Args: args}
+}
+
+// Chain creates a new script execution chain
+func (sr *ScriptRunner) Chain() *ScriptChain {
+	return NewChain(sr)
+}
+
+// NewChain creates a new script execution chain whose steps are run by runner
+func NewChain(runner Runner) *ScriptChain {
+	return &ScriptChain{
+		runner: runner,
+		steps:  make([]*chainStep, 0),
+	}
+}
+
+// Then adds a script to the execution chain
+func (sc *ScriptChain) Then(scriptName string, args ...string) *ScriptChain {
+	return sc.ThenCommand(NewCommand(scriptName, args...))
+}
+
+// ThenCommand adds a fully configured command to the execution chain
+func (sc *ScriptChain) ThenCommand(command Command) *ScriptChain {
+	sc.steps = append(sc.steps, &chainStep{commands: []Command{command}})
+	return sc
+}
+
+// Parallel adds a group of commands that run concurrently. The chain waits for the whole
+// group before the next step. By default the group uses one worker per CPU and FailFast.
+func (sc *ScriptChain) Parallel(commands ...Command) *ScriptChain {
+	sc.steps = append(sc.steps, &chainStep{
+		commands: commands,
+		parallel: true,
+		workers:  defaultWorkers(),
+		policy:   FailFast,
+	})
+	return sc
+}
+
+// Workers limits how many commands of the last added parallel group run at the same time
+func (sc *ScriptChain) Workers(workers int) *ScriptChain {
+	if step := sc.lastStep(); step != nil && step.parallel && workers > 0 {
+		step.workers = workers
+	}
+	return sc
+}
+
+// FailPolicy sets how the last added parallel group reacts when one of its steps fails
+func (sc *ScriptChain) FailPolicy(policy GroupPolicy) *ScriptChain {
+	if step := sc.lastStep(); step != nil && step.parallel {
+		step.policy = policy
+	}
+	return sc
+}
+
+// defaultWorkers returns the concurrency of parallel groups that do not set one
+func defaultWorkers() int {
+	return runtime.NumCPU()
+}
+
+// AllowFailure lets the last added step (or parallel group) fail without stopping the chain.
+// The failure is still recorded in its result.
+func (sc *ScriptChain) AllowFailure() *ScriptChain {
+	if step := sc.lastStep(); step != nil {
+		step.allowFailure = true
+	}
+	return sc
+}
+
+// Finally adds a step that always runs, even when an earlier step failed or the chain
+// context was cancelled. The runner default timeout still applies to it.
+func (sc *ScriptChain) Finally(scriptName string, args ...string) *ScriptChain {
+	sc.steps = append(sc.steps, &chainStep{commands: []Command{NewCommand(scriptName, args...)}, kind: stepFinally})
+	return sc
+}
+
+// OnFailure adds a step that runs only when an earlier step failed, e.g. to roll back
+// a half done release. Like Finally, it also runs after the chain context was cancelled.
+func (sc *ScriptChain) OnFailure(scriptName string, args ...string) *ScriptChain {
+	sc.steps = append(sc.steps, &chainStep{commands: []Command{NewCommand(scriptName, args...)}, kind: stepOnFailure})
+	return sc
+}
+
+// lastStep returns the last added step, nil for an empty chain
+func (sc *ScriptChain) lastStep() *chainStep {
+	if len(sc.steps) == 0 {
+		return nil
+	}
+	return sc.steps[len(sc.steps)-1]
+}
+
+// Execute runs all scripts in the chain until one fails
+func (sc *ScriptChain) Execute() (int, string, error) {
+	return sc.ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs all scripts in the chain until one fails or ctx is done.
+// The chain stops at the step that timed out or was cancelled.
+func (sc *ScriptChain) ExecuteContext(ctx context.Context) (int, string, error) {
+	return summarize(sc.Run(ctx))
+}
+
+// summarize returns the exit code and error of the first failure with the combined output
+func summarize(results []*ExecResult) (int, string, error) {
+	var combinedOutput strings.Builder
+	for _, result := range results {
+		combinedOutput.WriteString(result.Combined)
+	}
+
+	if failed := firstFailure(results); failed != nil {
+		return failed.ExitCode, combinedOutput.String(), failed.Err
+	}
+	return 0, combinedOutput.String(), nil
+}
+
+// Run executes the chain like ExecuteContext and returns the result of every step, in order.
+// Steps that did not run are reported as skipped with the reason in SkipReason.
+// When a journal is configured, a new journal is started.
+func (sc *ScriptChain) Run(ctx context.Context) []*ExecResult {
+	if sc.journalDir == "" || sc.isDryRun() {
+		return sc.run(ctx, nil)
+	}
+
+	journal, err := sc.newJournal()
+	if err != nil {
+		return sc.failBeforeRun(0, err)
+	}
+	return sc.run(ctx, journal)
+}
+
+// run executes the steps, skipping the normal steps the journal records as completed
+func (sc *ScriptChain) run(ctx context.Context, journal *chainJournal) []*ExecResult {
+	if sc.isDryRun() {
+		return sc.dryRun(ctx)
+	}
+
+	// Unresolvable output references fail the chain before anything runs
+	if invalid, err := sc.validate(); err != nil {
+		return sc.failBeforeRun(invalid, err)
+	}
+
+	all := make([]*ExecResult, 0, len(sc.steps))
+	var failed *ExecResult
+	outputs := map[string]string{}
+
+	for i, step := range sc.steps {
+		var results []*ExecResult
+
+		switch {
+		case step.kind == stepNormal && journal.completed(i):
+			results = skipStep(step, "completed in a previous run")
+			journal.restoreOutputs(i, outputs)
+		case step.kind == stepNormal && failed != nil:
+			results = skipStep(step, fmt.Sprintf("step '%s' failed", failed.Script))
+		case step.kind == stepOnFailure && failed == nil:
+			results = skipStep(step, "no earlier step failed")
+		default:
+			stepCtx := ctx
+			if step.kind != stepNormal {
+				// Cleanup steps must run even when the chain was cancelled
+				stepCtx = context.WithoutCancel(ctx)
+			}
+			results = sc.runStep(stepCtx, step, outputs)
+			for _, result := range results {
+				result.FailureAllowed = step.allowFailure
+			}
+			journal.record(i, results)
+		}
+
+		all = append(all, results...)
+
+		if failed == nil {
+			// Later normal steps are skipped once a script fails
+			failed = firstFailure(results)
+		}
+	}
+
+	if failed == nil {
+		journal.remove()
+	}
+
+	return sc.setResults(all)
+}
+
+// failBeforeRun reports err on the first command of the step at index invalid and skips
+// every other step, without running anything
+func (sc *ScriptChain) failBeforeRun(invalid int, err error) []*ExecResult {
+	all := make([]*ExecResult, 0, len(sc.steps))
+	for i, step := range sc.steps {
+		results := skipStep(step, "the chain could not start")
+		if i == invalid {
+			results[0] = notStarted(step.commands[0]).fail(err)
+		}
+		all = append(all, results...)
+	}
+	return sc.setResults(all)
+}
+
+// runStep runs a single command or a parallel group, expanding the output references of
+// its commands and collecting the outputs it declares
+func (sc *ScriptChain) runStep(ctx context.Context, step *chainStep, outputs map[string]string) []*ExecResult {
+	if err := ctx.Err(); err != nil {
+		// Do not start further steps once the context is done
+		results := make([]*ExecResult, 0, len(step.commands))
+		for _, command := range step.commands {
+			results = append(results, notStarted(command).fail(contextError(command.Script, err)))
+		}
+		return results
+	}
+
+	commands := make([]Command, len(step.commands))
+	outputFiles := make([]string, len(step.commands))
+
+	for i, command := range step.commands {
+		expanded, err := expandOutputs(command, outputs)
+		if err != nil {
+			results := skipStep(step, "a command of the step references a missing output")
+			results[i] = notStarted(command).fail(err)
+			return results
+		}
+
+		if len(step.outputs) > 0 {
+			// Give the script a file to write its outputs to
+			file, err := os.CreateTemp("", "devscripts-output-*")
+			if err != nil {
+				results := skipStep(step, "the output file could not be created")
+				results[i] = notStarted(command).fail(fmt.Errorf("error creating output file: %w", err))
+				return results
+			}
+			file.Close()
+			defer os.Remove(file.Name())
+
+			outputFiles[i] = file.Name()
+			expanded.Env = append(expanded.Env, OutputFileEnv+"="+file.Name())
+		}
+
+		commands[i] = expanded
+	}
+
+	var results []*ExecResult
+	if step.parallel {
+		results = sc.runGroup(ctx, step, commands)
+	} else {
+		results = []*ExecResult{sc.runner.Run(ctx, commands[0])}
+	}
+
+	for i, result := range results {
+		collectOutputs(step, result, outputFiles[i], outputs)
+	}
+	checkGroupOutputs(step, results)
+	return results
+}
+
+// skipStep returns skipped results for every command of the step
+func skipStep(step *chainStep, reason string) []*ExecResult {
+	results := make([]*ExecResult, 0, len(step.commands))
+	for _, command := range step.commands {
+		results = append(results, notStarted(command).skip(reason))
+	}
+	return results
+}
+
+// runGroup runs the commands of a parallel step with at most step.workers at a time.
+// Results are returned in the order the commands were declared.
+func (sc *ScriptChain) runGroup(ctx context.Context, step *chainStep, commands []Command) []*ExecResult {
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*ExecResult, len(commands))
+	workers := make(chan struct{}, max(step.workers, 1))
+	var failFast sync.Once
+	var wg sync.WaitGroup
+
+	for i, command := range commands {
+		workers <- struct{}{}
+
+		if groupCtx.Err() != nil {
+			// Fail fast: a previous command failed, do not start the pending ones
+			<-workers
+			results[i] = notStarted(command).skip("an earlier step of the parallel group failed")
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, command Command) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			result := sc.runner.Run(groupCtx, command)
+			switch {
+			case result.Success():
+			case ctx.Err() == nil && groupCtx.Err() != nil && errors.Is(result.Err, context.Canceled):
+				// Stopped by a failing sibling rather than by the caller
+				result.skip("stopped after another step of the parallel group failed")
+			case step.policy == FailFast:
+				failFast.Do(cancel)
+			}
+			results[i] = result
+		}(i, command)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// notStarted returns the result of a command that was never executed
+func notStarted(command Command) *ExecResult {
+	return &ExecResult{Script: command.Script, Args: command.Args, Dir: command.Dir, Start: time.Now()}
+}
+
+// firstFailure returns the first result that failed, ignoring skipped steps and
+// steps allowed to fail
+func firstFailure(results []*ExecResult) *ExecResult {
+	for _, result := range results {
+		if !result.Success() && !result.Skipped() && !result.FailureAllowed {
+			return result
+		}
+	}
+	return nil
+}
+
+// setResults stores the results of the run that finished last
+func (sc *ScriptChain) setResults(results []*ExecResult) []*ExecResult {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.results = results
+	return results
+}
+
+// Results returns the results of every step attempted by the last execution
+func (sc *ScriptChain) Results() []*ExecResult {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.results
+}
+
+// lastResult returns the result of the last executed script, or an empty result
+func (sc *ScriptChain) lastResult() *ExecResult {
+	results := sc.Results()
+	for i := len(results) - 1; i >= 0; i-- {
+		if !results[i].Skipped() {
+			return results[i]
+		}
+	}
+	return &ExecResult{}
+}
+
+// ExitCode returns the exit code of the last executed script
+func (sc *ScriptChain) ExitCode() int {
+	return sc.lastResult().ExitCode
+}
+
+// Output returns the output of the last executed script
+func (sc *ScriptChain) Output() string {
+	return sc.lastResult().Combined
+}
+
+// Error returns the error of the last executed script
+func (sc *ScriptChain) Error() error {
+	return sc.lastResult().Err
+}
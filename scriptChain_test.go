@@ -0,0 +1,215 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScripts creates executable scripts in dir from a name to content map
+func writeScripts(t *testing.T, dir string, scripts map[string]string) {
+	t.Helper()
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestScriptChainParallel(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"sleep.sh": "#!/bin/bash\nsleep \"$1\"\necho \"slept $1\"\n",
+		"after.sh": "#!/bin/bash\necho \"after group\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	// Three short steps with two workers take two rounds
+	results := runner.Chain().
+		Parallel(
+			NewCommand("sleep.sh", "0.5"),
+			NewCommand("sleep.sh", "0.5"),
+			NewCommand("sleep.sh", "0.5"),
+		).Workers(2).
+		Then("after.sh").
+		Run(context.Background())
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if !result.Success() {
+			t.Errorf("Step %d failed: %v", i, result.Err)
+		}
+	}
+	if results[3].Stdout != "after group\n" {
+		t.Errorf("Expected step after the group last, got %q", results[3].Stdout)
+	}
+	if !results[1].Start.Before(results[0].End) {
+		t.Error("Expected the first two steps to run concurrently")
+	}
+	firstDone := results[0].End
+	if results[1].End.Before(firstDone) {
+		firstDone = results[1].End
+	}
+	if results[2].Start.Before(firstDone) {
+		t.Error("Expected the third step to wait for a free worker")
+	}
+}
+
+func TestScriptChainParallelFailFast(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"sleep.sh": "#!/bin/bash\nsleep \"$1\"\necho \"slept $1\"\n",
+		"fail.sh":  "#!/bin/bash\necho \"failing\"\nexit 3\n",
+		"after.sh": "#!/bin/bash\necho \"after group\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	start := time.Now()
+	exitCode, output, err := runner.Chain().
+		Parallel(
+			NewCommand("sleep.sh", "30"),
+			NewCommand("fail.sh"),
+			NewCommand("sleep.sh", "30"),
+		).Workers(2).
+		Then("after.sh").
+		Execute()
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected fail fast to stop the running steps, took %v", time.Since(start))
+	}
+	if exitCode != 3 || err == nil {
+		t.Errorf("Expected the failing step to be reported, got %d %v", exitCode, err)
+	}
+	if strings.Contains(output, "after group") {
+		t.Error("Step after a failed group should not run")
+	}
+
+	chain := runner.Chain().
+		Parallel(NewCommand("sleep.sh", "30"), NewCommand("fail.sh"), NewCommand("sleep.sh", "30")).
+		Workers(2)
+	results := chain.Run(context.Background())
+
+	if len(results) != 3 {
+		t.Fatalf("Expected every step of the group to be reported, got %d", len(results))
+	}
+	if !results[0].Skipped() || !errors.Is(results[0].Err, ErrStepSkipped) {
+		t.Errorf("Expected running step to be stopped and marked skipped, got %v", results[0].Err)
+	}
+	if results[1].ExitCode != 3 || results[1].Skipped() {
+		t.Errorf("Expected failing step with exit code 3, got %d (%s)", results[1].ExitCode, results[1].SkipReason)
+	}
+	if !results[2].Skipped() || results[2].Argv != nil {
+		t.Errorf("Expected pending step to be skipped without running, got %+v", results[2])
+	}
+}
+
+func TestScriptChainParallelCollectAll(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"exit.sh": "#!/bin/bash\nsleep 0.2\necho \"exit $1\"\nexit \"$1\"\n",
+	})
+
+	results := NewScriptRunner(tempDir).Chain().
+		Parallel(
+			NewCommand("exit.sh", "0"),
+			NewCommand("exit.sh", "4"),
+			NewCommand("exit.sh", "5"),
+			NewCommand("exit.sh", "0"),
+		).Workers(1).FailPolicy(CollectAll).
+		Run(context.Background())
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	for i, want := range []int{0, 4, 5, 0} {
+		if results[i].ExitCode != want || results[i].Skipped() {
+			t.Errorf("Step %d: expected exit code %d, got %d (%s)", i, want, results[i].ExitCode, results[i].SkipReason)
+		}
+	}
+}
+
+func TestScriptChainFailureHooks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"step.sh":     "#!/bin/bash\necho \"step $1\"\nexit \"${2:-0}\"\n",
+		"cleanup.sh":  "#!/bin/bash\necho \"cleanup\"\n",
+		"rollback.sh": "#!/bin/bash\necho \"rollback\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	// A failure allowed step does not stop the chain and OnFailure is skipped
+	chain := runner.Chain().
+		Then("step.sh", "lint", "1").AllowFailure().
+		Then("step.sh", "tag").
+		OnFailure("rollback.sh").
+		Finally("cleanup.sh")
+
+	exitCode, output, err := chain.Execute()
+	if exitCode != 0 || err != nil {
+		t.Fatalf("Expected allowed failure to keep the chain successful, got %d %v", exitCode, err)
+	}
+	results := chain.Results()
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	if results[0].ExitCode != 1 || !results[0].FailureAllowed {
+		t.Errorf("Expected allowed failure to be recorded, got %d %v", results[0].ExitCode, results[0].FailureAllowed)
+	}
+	if !results[2].Skipped() || results[2].SkipReason != "no earlier step failed" {
+		t.Errorf("Expected rollback to be skipped, got %q", results[2].SkipReason)
+	}
+	if !strings.Contains(output, "step tag") || !strings.Contains(output, "cleanup") || strings.Contains(output, "rollback") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	// A real failure skips normal steps and runs OnFailure and Finally
+	chain = runner.Chain().
+		Then("step.sh", "tag", "2").
+		Then("step.sh", "publish").
+		OnFailure("rollback.sh").
+		Finally("cleanup.sh")
+
+	exitCode, output, err = chain.Execute()
+	if exitCode != 2 || err == nil {
+		t.Fatalf("Expected the failing step to be reported, got %d %v", exitCode, err)
+	}
+	results = chain.Results()
+	if !results[1].Skipped() || !strings.Contains(results[1].SkipReason, "step.sh") {
+		t.Errorf("Expected publish to be skipped because of the failed step, got %q", results[1].SkipReason)
+	}
+	if !results[2].Success() || !results[3].Success() {
+		t.Errorf("Expected rollback and cleanup to run, got %v %v", results[2].Err, results[3].Err)
+	}
+	if strings.Contains(output, "step publish") || !strings.Contains(output, "rollback") || !strings.Contains(output, "cleanup") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+	if chain.Output() != "cleanup\n" {
+		t.Errorf("Expected Output() to return the last executed script, got %q", chain.Output())
+	}
+
+	// Finally also runs after the chain context was cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, output, err = runner.Chain().Then("step.sh", "never").Finally("cleanup.sh").ExecuteContext(ctx)
+	if !errors.Is(err, context.Canceled) || output != "cleanup\n" {
+		t.Errorf("Expected only cleanup to run after cancellation, got %v %q", err, output)
+	}
+
+	// A failing Finally step fails the chain
+	if exitCode, _, _ := runner.Chain().Then("step.sh", "ok").Finally("step.sh", "cleanup", "6").Execute(); exitCode != 6 {
+		t.Errorf("Expected failing Finally to fail the chain, got %d", exitCode)
+	}
+}
@@ -0,0 +1,262 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrGraphCycle is returned (wrapped) when the steps of a ScriptGraph depend on each other in a cycle
+var ErrGraphCycle = errors.New("dependency cycle")
+
+// ScriptGraph runs scripts as a dependency graph: a step starts once every step it needs
+// succeeded, independent steps run concurrently and a failed step only skips its dependants
+type ScriptGraph struct {
+	runner  *ScriptRunner
+	nodes   []*graphNode
+	byID    map[string]*graphNode
+	workers int
+	results map[string]*ExecResult
+}
+
+// graphNode is a single step of a ScriptGraph
+type graphNode struct {
+	id       string
+	command  Command
+	needs    []string
+	children []*graphNode // Steps that need this one, filled by validate
+}
+
+// Graph creates a new dependency graph of scripts
+func (sr *ScriptRunner) Graph() *ScriptGraph {
+	return &ScriptGraph{
+		runner:  sr,
+		byID:    map[string]*graphNode{},
+		workers: defaultWorkers(),
+	}
+}
+
+// Add adds a step with the given id running the script with its arguments
+func (g *ScriptGraph) Add(id, scriptName string, args ...string) *ScriptGraph {
+	return g.AddCommand(id, NewCommand(scriptName, args...))
+}
+
+// AddCommand adds a step with the given id running a fully configured command
+func (g *ScriptGraph) AddCommand(id string, command Command) *ScriptGraph {
+	node := &graphNode{id: id, command: command}
+	g.nodes = append(g.nodes, node)
+	if _, exists := g.byID[id]; !exists {
+		g.byID[id] = node
+	}
+	return g
+}
+
+// Needs declares the steps that must succeed before the last added step starts
+func (g *ScriptGraph) Needs(ids ...string) *ScriptGraph {
+	if len(g.nodes) > 0 {
+		node := g.nodes[len(g.nodes)-1]
+		node.needs = append(node.needs, ids...)
+	}
+	return g
+}
+
+// Workers limits how many steps run at the same time, one per CPU by default
+func (g *ScriptGraph) Workers(workers int) *ScriptGraph {
+	if workers > 0 {
+		g.workers = workers
+	}
+	return g
+}
+
+// Validate checks for duplicated ids, unknown dependencies and cycles
+func (g *ScriptGraph) Validate() error {
+	_, err := g.validate()
+	return err
+}
+
+// validate returns the node at fault along with the error
+func (g *ScriptGraph) validate() (*graphNode, error) {
+	seen := map[string]bool{}
+	for _, node := range g.nodes {
+		if seen[node.id] {
+			return node, fmt.Errorf("step '%s' is declared twice", node.id)
+		}
+		seen[node.id] = true
+		for _, need := range node.needs {
+			if _, exists := g.byID[need]; !exists {
+				return node, fmt.Errorf("step '%s' needs unknown step '%s'", node.id, need)
+			}
+		}
+	}
+
+	// Depth first search, a node found again while still on the path closes a cycle
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(node *graphNode) error
+	visit = func(node *graphNode) error {
+		state[node.id] = onPath
+		path = append(path, node.id)
+		for _, need := range node.needs {
+			switch state[need] {
+			case onPath:
+				start := 0
+				for path[start] != need {
+					start++
+				}
+				cycle := append(append([]string{}, path[start:]...), need)
+				return fmt.Errorf("%w: %s", ErrGraphCycle, strings.Join(cycle, " -> "))
+			case unvisited:
+				if err := visit(g.byID[need]); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node.id] = done
+		return nil
+	}
+	for _, node := range g.nodes {
+		if state[node.id] == unvisited {
+			if err := visit(node); err != nil {
+				return node, err
+			}
+		}
+	}
+
+	for _, node := range g.nodes {
+		node.children = nil
+	}
+	for _, node := range g.nodes {
+		for _, need := range node.needs {
+			parent := g.byID[need]
+			parent.children = append(parent.children, node)
+		}
+	}
+
+	return nil, nil
+}
+
+// Execute runs the graph and returns the first failure in declaration order
+func (g *ScriptGraph) Execute() (int, string, error) {
+	return g.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is like Execute but stops when ctx is done
+func (g *ScriptGraph) ExecuteContext(ctx context.Context) (int, string, error) {
+	g.Run(ctx)
+	return summarize(g.ordered())
+}
+
+// Run executes the graph and returns the result of every step by id. Steps whose
+// dependencies failed are reported as skipped. An invalid graph runs nothing.
+func (g *ScriptGraph) Run(ctx context.Context) map[string]*ExecResult {
+	g.results = make(map[string]*ExecResult, len(g.nodes))
+
+	if invalid, err := g.validate(); err != nil {
+		for _, node := range g.nodes {
+			if _, exists := g.results[node.id]; !exists {
+				g.results[node.id] = notStarted(node.command).skip("the graph could not start")
+			}
+		}
+		if invalid != nil {
+			g.results[invalid.id] = notStarted(invalid.command).fail(err)
+		}
+		return g.results
+	}
+
+	type finished struct {
+		node   *graphNode
+		result *ExecResult
+	}
+
+	pending := make(map[string]int, len(g.nodes))
+	var ready []*graphNode
+	for _, node := range g.nodes {
+		pending[node.id] = len(node.needs)
+		if len(node.needs) == 0 {
+			ready = append(ready, node)
+		}
+	}
+
+	done := make(chan finished)
+	running := 0
+
+	for len(ready) > 0 || running > 0 {
+		// Start as many ready steps as the workers allow
+		for len(ready) > 0 && running < g.workers {
+			node := ready[0]
+			ready = ready[1:]
+
+			if err := ctx.Err(); err != nil {
+				g.results[node.id] = notStarted(node.command).fail(contextError(node.command.Script, err))
+				g.skipDependants(node)
+				continue
+			}
+
+			running++
+			go func(node *graphNode) {
+				done <- finished{node, g.runner.Run(ctx, node.command)}
+			}(node)
+		}
+
+		if running == 0 {
+			continue
+		}
+
+		f := <-done
+		running--
+		g.results[f.node.id] = f.result
+
+		if !f.result.Success() {
+			g.skipDependants(f.node)
+			continue
+		}
+		for _, child := range f.node.children {
+			pending[child.id]--
+			if pending[child.id] == 0 {
+				if _, skipped := g.results[child.id]; !skipped {
+					ready = append(ready, child)
+				}
+			}
+		}
+	}
+
+	return g.results
+}
+
+// skipDependants marks every step that directly or indirectly needs the failed step as skipped
+func (g *ScriptGraph) skipDependants(failed *graphNode) {
+	var skip func(node *graphNode)
+	skip = func(node *graphNode) {
+		for _, child := range node.children {
+			if _, exists := g.results[child.id]; exists {
+				continue
+			}
+			g.results[child.id] = notStarted(child.command).skip(fmt.Sprintf("needed step '%s' failed", failed.id))
+			skip(child)
+		}
+	}
+	skip(failed)
+}
+
+// Results returns the result of every step of the last run by id
+func (g *ScriptGraph) Results() map[string]*ExecResult {
+	return g.results
+}
+
+// ordered returns the results of the last run in declaration order
+func (g *ScriptGraph) ordered() []*ExecResult {
+	results := make([]*ExecResult, 0, len(g.results))
+	for _, node := range g.nodes {
+		if result, exists := g.results[node.id]; exists {
+			results = append(results, result)
+		}
+	}
+	return results
+}
@@ -0,0 +1,111 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScriptGraph(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"step.sh": "#!/bin/bash\nsleep 0.3\necho \"$1\"\nexit \"${2:-0}\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	graph := runner.Graph().
+		Add("test", "step.sh", "test").
+		Add("vet", "step.sh", "vet").
+		Add("tag", "step.sh", "tag").Needs("test", "vet").
+		Add("publish", "step.sh", "publish").Needs("tag").
+		Workers(2)
+
+	results := graph.Run(context.Background())
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	for id, result := range results {
+		if !result.Success() || strings.TrimSpace(result.Stdout) != id {
+			t.Errorf("Step %s: unexpected result %d %v %q", id, result.ExitCode, result.Err, result.Stdout)
+		}
+	}
+
+	// Independent steps run together, dependants wait for all their needs
+	if !results["vet"].Start.Before(results["test"].End) || !results["test"].Start.Before(results["vet"].End) {
+		t.Error("Expected test and vet to run concurrently")
+	}
+	if results["tag"].Start.Before(results["test"].End) || results["tag"].Start.Before(results["vet"].End) {
+		t.Error("Expected tag to start after test and vet")
+	}
+	if results["publish"].Start.Before(results["tag"].End) {
+		t.Error("Expected publish to start after tag")
+	}
+}
+
+func TestScriptGraphFailure(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"step.sh": "#!/bin/bash\necho \"$1\"\nexit \"${2:-0}\"\n",
+	})
+
+	graph := NewScriptRunner(tempDir).Graph().
+		Add("test", "step.sh", "test", "2").
+		Add("vet", "step.sh", "vet").
+		Add("docs", "step.sh", "docs").Needs("vet").
+		Add("tag", "step.sh", "tag").Needs("test", "vet").
+		Add("publish", "step.sh", "publish").Needs("tag")
+
+	exitCode, output, err := graph.Execute()
+	if exitCode != 2 || err == nil {
+		t.Errorf("Expected failure of test to be reported, got %d %v", exitCode, err)
+	}
+	if !strings.Contains(output, "docs") {
+		t.Errorf("Expected independent branch to keep running, got %q", output)
+	}
+
+	results := graph.Results()
+	if !results["vet"].Success() || !results["docs"].Success() {
+		t.Error("Expected steps not depending on test to succeed")
+	}
+	for _, id := range []string{"tag", "publish"} {
+		if !results[id].Skipped() || results[id].SkipReason != "needed step 'test' failed" {
+			t.Errorf("Expected %s to be skipped because test failed, got %q", id, results[id].SkipReason)
+		}
+	}
+}
+
+func TestScriptGraphValidation(t *testing.T) {
+	runner := NewScriptRunner(t.TempDir())
+
+	cyclic := runner.Graph().
+		Add("a", "a.sh").Needs("c").
+		Add("b", "b.sh").Needs("a").
+		Add("c", "c.sh").Needs("b")
+
+	err := cyclic.Validate()
+	if !errors.Is(err, ErrGraphCycle) || !strings.Contains(err.Error(), "a -> c -> b -> a") {
+		t.Errorf("Expected cycle error with its path, got %v", err)
+	}
+
+	// Nothing runs for an invalid graph
+	results := cyclic.Run(context.Background())
+	for id, result := range results {
+		if result.Argv != nil {
+			t.Errorf("Step %s should not have run", id)
+		}
+	}
+	if _, _, err := cyclic.Execute(); !errors.Is(err, ErrGraphCycle) {
+		t.Errorf("Expected Execute to report the cycle, got %v", err)
+	}
+
+	if err := runner.Graph().Add("a", "a.sh").Needs("missing").Validate(); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected unknown dependency error, got %v", err)
+	}
+	if err := runner.Graph().Add("a", "a.sh").Add("a", "b.sh").Validate(); err == nil {
+		t.Error("Expected duplicated id error")
+	}
+}
@@ -0,0 +1,65 @@
+package devscripts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// ErrScriptModeReadOnly is returned (wrapped) when a script lacks the executable bits and
+// its mode cannot be changed, e.g. on a read-only mount or a file owned by another user.
+// The script is still run through its interpreter.
+var ErrScriptModeReadOnly = errors.New("script is not executable and its mode cannot be changed")
+
+// ModePolicy selects whether the runner changes the file mode of scripts before running them
+type ModePolicy int
+
+const (
+	// ModeKeep never changes file modes, the default. Scripts are run through their
+	// interpreter, so they do not need the executable bits.
+	ModeKeep ModePolicy = iota
+	// ModeAddExecutable adds the missing executable bits, leaving executable scripts untouched
+	ModeAddExecutable
+)
+
+// chmod changes file modes, replaced in tests
+var chmod = os.Chmod
+
+// WithModePolicy chooses whether the runner changes the file mode of scripts
+func WithModePolicy(policy ModePolicy) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.modePolicy = policy
+	}
+}
+
+// makeScriptsExecutable makes the specified script executable if needed
+func (sr *ScriptRunner) makeScriptsExecutable(scriptPath string) error {
+	// On Windows it's not necessary to make scripts executable
+	if runtime.GOOS == "windows" || sr.modePolicy == ModeKeep {
+		return nil
+	}
+
+	info, err := os.Stat(scriptPath)
+	if err != nil {
+		return fmt.Errorf("failed to check mode of script %s: %w", scriptPath, err)
+	}
+
+	// Give execute permission to everyone who can read the script
+	mode := info.Mode().Perm()
+	wanted := mode | (mode&0444)>>2
+	if mode == wanted {
+		return nil
+	}
+
+	if err := chmod(scriptPath, wanted); err != nil {
+		if errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS) {
+			return fmt.Errorf("%w: %s (%s): %w", ErrScriptModeReadOnly, scriptPath, mode, err)
+		}
+		return fmt.Errorf("failed to make script %s executable: %w", scriptPath, err)
+	}
+
+	return nil
+}
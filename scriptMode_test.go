@@ -0,0 +1,94 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestModePolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not changed on Windows")
+	}
+
+	tempDir := t.TempDir()
+
+	writeScript := func(name string, mode os.FileMode) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/bash\necho ok\n"), mode); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("Failed to set mode of %s: %v", name, err)
+		}
+		return path
+	}
+	modeOf := func(path string) os.FileMode {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		return info.Mode().Perm()
+	}
+
+	plain := writeScript("plain.sh", 0640)
+	executable := writeScript("executable.sh", 0700)
+
+	// By default modes are never changed and the script still runs
+	kept := writeScript("kept.sh", 0644)
+	runner := NewScriptRunner(tempDir)
+	if exitCode, _, err := runner.ExecScript("kept.sh"); err != nil || exitCode != 0 {
+		t.Fatalf("Expected success, got %d %v", exitCode, err)
+	}
+	if mode := modeOf(kept); mode != 0644 {
+		t.Errorf("Expected mode 0644 to be kept, got %o", mode)
+	}
+
+	runner = NewScriptRunnerWithOptions(tempDir, WithModePolicy(ModeAddExecutable))
+
+	// Missing executable bits are added for those who can read the script
+	if exitCode, _, err := runner.ExecScript("plain.sh"); err != nil || exitCode != 0 {
+		t.Fatalf("Expected success, got %d %v", exitCode, err)
+	}
+	if mode := modeOf(plain); mode != 0750 {
+		t.Errorf("Expected mode 0750, got %o", mode)
+	}
+
+	// Executable scripts are left untouched
+	if exitCode, _, err := runner.ExecScript("executable.sh"); err != nil || exitCode != 0 {
+		t.Fatalf("Expected success, got %d %v", exitCode, err)
+	}
+	if mode := modeOf(executable); mode != 0700 {
+		t.Errorf("Expected mode 0700 to be kept, got %o", mode)
+	}
+}
+
+func TestModePolicyReadOnly(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not changed on Windows")
+	}
+
+	// Simulate a read-only mount
+	defer func(original func(string, os.FileMode) error) { chmod = original }(chmod)
+	chmod = func(name string, mode os.FileMode) error {
+		return &os.PathError{Op: "chmod", Path: name, Err: syscall.EROFS}
+	}
+
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{"readonly.sh": "#!/bin/bash\necho ok\n"})
+	os.Chmod(filepath.Join(tempDir, "readonly.sh"), 0644)
+
+	runner := NewScriptRunnerWithOptions(tempDir, WithModePolicy(ModeAddExecutable))
+
+	result := runner.Run(context.Background(), Command{Script: "readonly.sh"})
+	if !errors.Is(result.ModeErr, ErrScriptModeReadOnly) {
+		t.Errorf("Expected ErrScriptModeReadOnly, got %v", result.ModeErr)
+	}
+	if !result.Success() || result.Stdout != "ok\n" {
+		t.Errorf("Expected script to run despite the mode error, got %d %v", result.ExitCode, result.Err)
+	}
+}
@@ -0,0 +1,145 @@
+package devscripts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is the number of similar names offered when a script is not found
+const maxSuggestions = 3
+
+// ScriptNotFoundError is returned when no search directory has the script
+type ScriptNotFoundError struct {
+	Script      string
+	Dirs        []string // Directories searched, in order
+	Suggestions []string // Similar script names, closest first
+}
+
+func (e *ScriptNotFoundError) Error() string {
+	msg := fmt.Sprintf("error: script '%s' does not exist in %s", e.Script, strings.Join(e.Dirs, ", "))
+	switch len(e.Suggestions) {
+	case 0:
+		return msg
+	case 1:
+		return fmt.Sprintf("%s; did you mean '%s'?", msg, e.Suggestions[0])
+	default:
+		return fmt.Sprintf("%s; did you mean one of '%s'?", msg, strings.Join(e.Suggestions, "', '"))
+	}
+}
+
+// WithSearchPaths adds directories searched for scripts before scriptsDir, in order, so
+// project-local scripts override the shared ones with the same name
+func WithSearchPaths(dirs ...string) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.searchPaths = append(sr.searchPaths, dirs...)
+	}
+}
+
+// SearchPaths returns the directories searched for scripts, in order
+func (sr *ScriptRunner) SearchPaths() []string {
+	return append(slices.Clone(sr.searchPaths), sr.scriptsDir)
+}
+
+// candidateNames returns the file names the script name may refer to, in order. A name
+// without extension also matches a file with any registered extension, .sh first and then
+// the others in sorted order, so "sectionUpdate" picks sectionUpdate.sh over sectionUpdate.go.
+func (sr *ScriptRunner) candidateNames(scriptName string) []string {
+	names := []string{scriptName}
+	if filepath.Ext(scriptName) != "" {
+		return names
+	}
+
+	exts := sr.Extensions()
+	if i := slices.Index(exts, ".sh"); i > 0 {
+		exts = append([]string{".sh"}, slices.Delete(exts, i, i+1)...)
+	}
+	for _, ext := range exts {
+		names = append(names, scriptName+ext)
+	}
+	return names
+}
+
+// findScript returns the path of the script in the first search directory that has it.
+// A name without extension is looked up as described in candidateNames.
+func (sr *ScriptRunner) findScript(scriptName string) (string, error) {
+	dirs := sr.SearchPaths()
+	names := sr.candidateNames(scriptName)
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+
+	return "", &ScriptNotFoundError{Script: scriptName, Dirs: dirs, Suggestions: suggestScripts(scriptName, dirs)}
+}
+
+// suggestScripts returns the file names in dirs closest to name by edit distance
+func suggestScripts(name string, dirs []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	// Typos up to a third of the name are considered, at least two
+	limit := max(2, len(name)/3)
+	bare := filepath.Ext(name) == ""
+
+	var candidates []candidate
+	for _, dir := range dirs {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if entry.IsDir() || slices.ContainsFunc(candidates, func(c candidate) bool { return c.name == entry.Name() }) {
+				continue
+			}
+			distance := editDistance(name, entry.Name())
+			if bare {
+				distance = min(distance, editDistance(name, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))))
+			}
+			if distance <= limit {
+				candidates = append(candidates, candidate{entry.Name(), distance})
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
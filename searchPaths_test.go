@@ -0,0 +1,102 @@
+package devscripts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchPaths(t *testing.T) {
+	shared := t.TempDir()
+	local := t.TempDir()
+	writeScripts(t, shared, map[string]string{
+		"gitpush.sh":  "#!/bin/bash\necho shared push\n",
+		"gotest.sh":   "#!/bin/bash\necho shared test\n",
+		"gitclone.sh": "#!/bin/bash\necho shared clone\n",
+	})
+	writeScripts(t, local, map[string]string{
+		"gitpush.sh": "#!/bin/bash\necho local push\n",
+	})
+
+	runner := NewScriptRunnerWithOptions(shared, WithSearchPaths(local))
+
+	if dirs := runner.SearchPaths(); len(dirs) != 2 || dirs[0] != local || dirs[1] != shared {
+		t.Errorf("Expected local before shared, got %v", dirs)
+	}
+
+	// Project-local scripts override the shared ones
+	if _, output, err := runner.ExecScript("gitpush.sh"); err != nil || output != "local push\n" {
+		t.Errorf("Expected the local override to run, got %q %v", output, err)
+	}
+	if _, output, err := runner.ExecScript("gotest.sh"); err != nil || output != "shared test\n" {
+		t.Errorf("Expected the shared script to run, got %q %v", output, err)
+	}
+
+	// Bare names match any registered extension
+	result := runner.Run(t.Context(), Command{Script: "gotest"})
+	if !result.Success() || result.Path != filepath.Join(shared, "gotest.sh") {
+		t.Errorf("Expected gotest to resolve to gotest.sh, got %q %v", result.Path, result.Err)
+	}
+
+	// A miss suggests similar names instead of listing the directory
+	_, _, err := runner.ExecScript("gitpus.sh")
+	var notFound *ScriptNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Expected ScriptNotFoundError, got %v", err)
+	}
+	if len(notFound.Suggestions) == 0 || notFound.Suggestions[0] != "gitpush.sh" {
+		t.Errorf("Expected gitpush.sh to be suggested first, got %v", notFound.Suggestions)
+	}
+	if !strings.Contains(err.Error(), "did you mean") || strings.Contains(err.Error(), "gotest.sh") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	_, _, err = runner.ExecScript("deploy")
+	if !errors.As(err, &notFound) || len(notFound.Suggestions) != 0 {
+		t.Errorf("Expected no suggestion for an unrelated name, got %v", err)
+	}
+}
+
+func TestSearchPathsBareName(t *testing.T) {
+	tempDir := t.TempDir()
+	writeScripts(t, tempDir, map[string]string{
+		"tool.go": "package tool\n",
+		"tool.py": "print('python')\n",
+		"tool.sh": "#!/bin/bash\necho \"shell\"\n",
+	})
+
+	// The .sh file wins over the files sharing its base name
+	result := NewScriptRunner(tempDir).Run(context.Background(), Command{Script: "tool"})
+	if result.Path != filepath.Join(tempDir, "tool.sh") || result.Stdout != "shell\n" {
+		t.Errorf("Expected tool.sh to run, got %s %q %v", result.Path, result.Stdout, result.Err)
+	}
+
+	// The other extensions follow in sorted order
+	if err := os.Remove(filepath.Join(tempDir, "tool.sh")); err != nil {
+		t.Fatalf("Failed to remove tool.sh: %v", err)
+	}
+	if plan := NewScriptRunner(tempDir).PlanCommand(Command{Script: "tool"}); plan.Path != filepath.Join(tempDir, "tool.go") {
+		t.Errorf("Expected tool.go after tool.sh, got %s", plan.Path)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"gitpush", "gitpush", 0},
+		{"gitpus", "gitpush", 1},
+		{"gitpsuh", "gitpush", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
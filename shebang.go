@@ -0,0 +1,80 @@
+package devscripts
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// InterpreterPrecedence selects how the interpreter of a script is chosen
+type InterpreterPrecedence int
+
+const (
+	// PreferShebang uses the #! line of the script and falls back to the extension map,
+	// the default outside Windows. Interpreters registered with RegisterInterpreter or
+	// WithInterpreter still win over #! lines.
+	PreferShebang InterpreterPrecedence = iota
+	// PreferExtension uses the extension map and falls back to the #! line of the script
+	PreferExtension
+	// ExtensionOnly ignores #! lines and only uses the extension map
+	ExtensionOnly
+)
+
+// defaultPrecedence returns the precedence of a new runner. Windows ignores shebangs
+// by default because their Unix paths do not exist there.
+func defaultPrecedence() InterpreterPrecedence {
+	if runtime.GOOS == "windows" {
+		return PreferExtension
+	}
+	return PreferShebang
+}
+
+// WithInterpreterPrecedence chooses whether #! lines or file extensions decide the interpreter
+func WithInterpreterPrecedence(precedence InterpreterPrecedence) RunnerOption {
+	return func(sr *ScriptRunner) {
+		sr.precedence = precedence
+	}
+}
+
+// readShebang returns the interpreter declared in the first line of the script, if any
+func readShebang(scriptPath string) (Interpreter, bool) {
+	file, err := os.Open(scriptPath)
+	if err != nil {
+		return Interpreter{}, false
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && line == "" {
+		return Interpreter{}, false
+	}
+
+	return parseShebang(line)
+}
+
+// parseShebang parses a "#!" line such as "#!/bin/bash -e" or "#!/usr/bin/env -S python3 -u"
+func parseShebang(line string) (Interpreter, bool) {
+	if !strings.HasPrefix(line, "#!") {
+		return Interpreter{}, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return Interpreter{}, false
+	}
+
+	if path.Base(fields[0]) == "env" {
+		// Skip env flags (like -S) and variable assignments to find the program
+		fields = fields[1:]
+		for len(fields) > 0 && (strings.HasPrefix(fields[0], "-") || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return Interpreter{}, false
+		}
+	}
+
+	return Interpreter{Command: fields[0], Args: fields[1:]}, true
+}
@@ -0,0 +1,102 @@
+package devscripts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseShebang(t *testing.T) {
+	tests := []struct {
+		line    string
+		command string
+		args    []string
+		found   bool
+	}{
+		{"#!/bin/bash\n", "/bin/bash", nil, true},
+		{"#!/bin/bash -e\n", "/bin/bash", []string{"-e"}, true},
+		{"#! /usr/bin/env zsh\n", "zsh", nil, true},
+		{"#!/usr/bin/env python3 -u\r\n", "python3", []string{"-u"}, true},
+		{"#!/usr/bin/env -S LANG=C node --no-warnings\n", "node", []string{"--no-warnings"}, true},
+		{"#!/usr/bin/env\n", "", nil, false},
+		{"#!\n", "", nil, false},
+		{"echo hello\n", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		interpreter, found := parseShebang(tt.line)
+		if found != tt.found {
+			t.Errorf("parseShebang(%q) found = %v, want %v", tt.line, found, tt.found)
+			continue
+		}
+		if interpreter.Command != tt.command {
+			t.Errorf("parseShebang(%q) command = %q, want %q", tt.line, interpreter.Command, tt.command)
+		}
+		if strings.Join(interpreter.Args, " ") != strings.Join(tt.args, " ") {
+			t.Errorf("parseShebang(%q) args = %v, want %v", tt.line, interpreter.Args, tt.args)
+		}
+	}
+}
+
+// shInterpreter returns the interpreter a new runner picks for a .sh script starting with
+// the #! line: the line itself, or the extension on Windows
+func shInterpreter(line string) string {
+	if shebang, ok := parseShebang(line); ok && defaultPrecedence() == PreferShebang {
+		return shebang.Command
+	}
+	return defaultInterpreters()[".sh"].Command
+}
+
+func TestInterpreterPrecedence(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Extensionless script only runnable through its #! line
+	err := os.WriteFile(filepath.Join(tempDir, "noext"), []byte("#!/usr/bin/env sh\necho \"ran noext\"\n"), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	// .sh script whose #! line asks for another interpreter
+	err = os.WriteFile(filepath.Join(tempDir, "other.sh"), []byte("#!/usr/bin/env cat\necho \"ran by shell\"\n"), 0755)
+	if err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	runner := NewScriptRunner(tempDir)
+
+	exitCode, output, err := runner.ExecScript("noext")
+	if err != nil || exitCode != 0 || !strings.Contains(output, "ran noext") {
+		t.Errorf("Expected extensionless script to run through its #! line, got %d %v %q", exitCode, err, output)
+	}
+
+	// By default the #! line wins over the built-in interpreters, so cat prints the script source
+	_, output, _ = runner.ExecScript("other.sh")
+	if !strings.Contains(output, "#!/usr/bin/env cat") {
+		t.Errorf("Expected #! interpreter to be used, got %q", output)
+	}
+
+	// A registered interpreter wins over the #! line
+	_, output, _ = NewScriptRunnerWithOptions(tempDir, WithInterpreter(".sh", "sh")).ExecScript("other.sh")
+	if strings.TrimSpace(output) != "ran by shell" {
+		t.Errorf("Expected the registered interpreter to be used, got %q", output)
+	}
+
+	// With extension precedence bash runs the script
+	runner = NewScriptRunnerWithOptions(tempDir, WithInterpreterPrecedence(PreferExtension))
+	_, output, _ = runner.ExecScript("other.sh")
+	if strings.TrimSpace(output) != "ran by shell" {
+		t.Errorf("Expected extension interpreter to be used, got %q", output)
+	}
+
+	// Extension precedence still falls back to the #! line
+	if exitCode, _, err := runner.ExecScript("noext"); err != nil || exitCode != 0 {
+		t.Errorf("Expected fallback to #! line, got %d %v", exitCode, err)
+	}
+
+	// Extension only never reads the #! line
+	runner = NewScriptRunnerWithOptions(tempDir, WithInterpreterPrecedence(ExtensionOnly))
+	if _, _, err := runner.ExecScript("noext"); err == nil {
+		t.Error("Expected extensionless script to be unsupported with ExtensionOnly")
+	}
+}
@@ -1,8 +1,9 @@
 package devscripts
 
 import (
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 )
 
@@ -15,24 +16,30 @@ type ScriptInfo struct {
 
 // ScriptParser handles parsing of shell scripts
 type ScriptParser struct {
-	scriptsDir string
+	fsys fs.FS
 }
 
 // NewScriptParser creates a new ScriptParser
 func NewScriptParser(scriptsDir string) *ScriptParser {
-	return &ScriptParser{scriptsDir: scriptsDir}
+	return NewScriptParserFS(os.DirFS(scriptsDir))
+}
+
+// NewScriptParserFS creates a ScriptParser for the scripts at the root of fsys,
+// e.g. EmbeddedScripts
+func NewScriptParserFS(fsys fs.FS) *ScriptParser {
+	return &ScriptParser{fsys: fsys}
 }
 
 // GetScriptNames obtiene los nombres de los scripts .sh en el directorio
 func (sp *ScriptParser) GetScriptNames() ([]string, error) {
-	files, err := os.ReadDir(sp.scriptsDir)
+	files, err := fs.ReadDir(sp.fsys, ".")
 	if err != nil {
 		return nil, err
 	}
 
 	var scripts []string
 	for _, f := range files {
-		if filepath.Ext(f.Name()) == ".sh" {
+		if path.Ext(f.Name()) == ".sh" {
 			scripts = append(scripts, f.Name())
 		}
 	}
@@ -50,7 +57,7 @@ func (sp *ScriptParser) ParseScripts() ([]ScriptInfo, error) {
 	var scriptsStruct []ScriptInfo
 
 	for _, script := range scripts {
-		content, err := os.ReadFile(filepath.Join(sp.scriptsDir, script))
+		content, err := fs.ReadFile(sp.fsys, script)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,216 @@
+package devscripts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Workflow is the JSON definition of a ScriptChain
+//
+//	{
+//	  "name": "release",
+//	  "steps": [
+//	    {"parallel": [{"script": "gotest.sh"}, {"script": "govet.sh"}], "workers": 2},
+//	    {"script": "golint.sh", "allow_failure": true},
+//	    {"script": "nextversion.sh", "outputs": ["tag"]},
+//	    {"script": "gomodtagupdate.sh", "args": ["mypkg", "{{tag}}"], "env": {"GOFLAGS": "-mod=mod"}},
+//	    {"script": "tagdelete.sh", "args": ["{{tag}}"], "when": "failure"},
+//	    {"script": "cleanup.sh", "when": "always"}
+//	  ]
+//	}
+type Workflow struct {
+	Name  string         `json:"name"`
+	Steps []WorkflowStep `json:"steps"`
+}
+
+// WorkflowStep is a single script or a parallel group of a Workflow
+type WorkflowStep struct {
+	WorkflowCommand
+	Parallel     []WorkflowCommand `json:"parallel,omitempty"`      // Commands run concurrently instead of Script
+	Workers      int               `json:"workers,omitempty"`       // Concurrency of the parallel group
+	FailPolicy   string            `json:"fail_policy,omitempty"`   // "fail_fast" (default) or "collect_all"
+	When         string            `json:"when,omitempty"`          // "success" (default), "failure" or "always"
+	AllowFailure bool              `json:"allow_failure,omitempty"` // The step may fail without stopping the chain
+	Outputs      []string          `json:"outputs,omitempty"`       // Outputs set by the step
+}
+
+// WorkflowCommand is the JSON form of a Command
+type WorkflowCommand struct {
+	Script string            `json:"script,omitempty"`
+	Args   []string          `json:"args,omitempty"`
+	Env    map[string]string `json:"env,omitempty"`
+	Dir    string            `json:"dir,omitempty"`
+	Input  string            `json:"input,omitempty"`
+}
+
+// LoadChain reads a JSON workflow file and builds the chain it describes. The chain is
+// validated against the scripts and interpreters this runner can find before it is returned.
+func (sr *ScriptRunner) LoadChain(path string) (*ScriptChain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workflow: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var workflow Workflow
+	if err := decoder.Decode(&workflow); err != nil {
+		return nil, fmt.Errorf("error parsing workflow %s: %w", path, err)
+	}
+
+	if workflow.Name == "" {
+		// Default the chain name to the file name without extension
+		base := filepath.Base(path)
+		workflow.Name = base[:len(base)-len(filepath.Ext(base))]
+	}
+
+	return sr.ChainFromWorkflow(workflow)
+}
+
+// ChainFromWorkflow builds and validates the chain described by the workflow
+func (sr *ScriptRunner) ChainFromWorkflow(workflow Workflow) (*ScriptChain, error) {
+	chain := sr.Chain()
+	chain.name = workflow.Name
+
+	var errs []error
+	for i, ws := range workflow.Steps {
+		step, err := ws.chainStep()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("step %d: %w", i+1, err))
+			continue
+		}
+
+		for _, command := range step.commands {
+			if _, _, err := sr.resolve(command.Script); err != nil {
+				errs = append(errs, fmt.Errorf("step %d: %w", i+1, err))
+			}
+		}
+
+		chain.steps = append(chain.steps, step)
+	}
+
+	if len(errs) == 0 {
+		if err := chain.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid workflow %q: %w", workflow.Name, errors.Join(errs...))
+	}
+	return chain, nil
+}
+
+// chainStep converts the workflow step into a chain step
+func (ws WorkflowStep) chainStep() (*chainStep, error) {
+	step := &chainStep{
+		allowFailure: ws.AllowFailure,
+		outputs:      ws.Outputs,
+	}
+
+	switch ws.When {
+	case "", "success":
+		step.kind = stepNormal
+	case "failure":
+		step.kind = stepOnFailure
+	case "always":
+		step.kind = stepFinally
+	default:
+		return nil, fmt.Errorf("unknown when %q, use success, failure or always", ws.When)
+	}
+
+	switch {
+	case ws.Script != "" && len(ws.Parallel) > 0:
+		return nil, errors.New("a step sets either script or parallel, not both")
+	case len(ws.Parallel) > 0:
+		step.parallel = true
+		step.workers = ws.Workers
+		if step.workers <= 0 {
+			step.workers = defaultWorkers()
+		}
+		switch ws.FailPolicy {
+		case "", "fail_fast":
+			step.policy = FailFast
+		case "collect_all":
+			step.policy = CollectAll
+		default:
+			return nil, fmt.Errorf("unknown fail_policy %q, use fail_fast or collect_all", ws.FailPolicy)
+		}
+		for _, wc := range ws.Parallel {
+			if wc.Script == "" {
+				return nil, errors.New("parallel command without script")
+			}
+			step.commands = append(step.commands, wc.command())
+		}
+	case ws.Script != "":
+		step.commands = []Command{ws.WorkflowCommand.command()}
+	default:
+		return nil, errors.New("step without script")
+	}
+
+	return step, nil
+}
+
+// command converts the workflow command into a Command
+func (wc WorkflowCommand) command() Command {
+	keys := make([]string, 0, len(wc.Env))
+	for key := range wc.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, key+"="+wc.Env[key])
+	}
+
+	return Command{
+		Script: wc.Script,
+		Args:   wc.Args,
+		Env:    env,
+		Dir:    wc.Dir,
+		Input:  wc.Input,
+	}
+}
+
+// RunWorkflow is a small CLI-style entry that runs a JSON workflow file.
+//
+// Args order: workflowFile (required), scriptsDir (optional, default: current directory).
+func RunWorkflow(args ...string) {
+	if len(args) == 0 {
+		fmt.Println("Error: workflow file required")
+		os.Exit(1)
+	}
+
+	scriptsDir := ""
+	if len(args) > 1 {
+		scriptsDir = args[1]
+	}
+
+	runner := NewScriptRunnerWithOptions(scriptsDir, WithOutput(os.Stdout, os.Stderr))
+	chain, err := runner.LoadChain(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	exitCode, _, err := chain.Execute()
+	for _, result := range chain.Results() {
+		if result.Skipped() {
+			fmt.Printf("skipped %s: %s\n", result.Script, result.SkipReason)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if exitCode <= 0 {
+			exitCode = 1
+		}
+		os.Exit(exitCode)
+	}
+}
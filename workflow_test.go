@@ -0,0 +1,109 @@
+package devscripts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadChain(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"check.sh":    "#!/bin/bash\necho \"check $1\"\n",
+		"lint.sh":     "#!/bin/bash\necho \"lint\"\nexit 1\n",
+		"version.sh":  "#!/bin/bash\necho \"::output::tag=v2.0.0\"\n",
+		"tag.sh":      "#!/bin/bash\nread answer\necho \"tag $1 $GOFLAGS $answer\"\n",
+		"rollback.sh": "#!/bin/bash\necho \"rollback\"\n",
+		"cleanup.sh":  "#!/bin/bash\necho \"cleanup\"\n",
+	})
+
+	workflowPath := filepath.Join(tempDir, "release.json")
+	err := os.WriteFile(workflowPath, []byte(`{
+  "steps": [
+    {"parallel": [{"script": "check.sh", "args": ["test"]}, {"script": "check.sh", "args": ["vet"]}], "workers": 2, "fail_policy": "collect_all"},
+    {"script": "lint.sh", "allow_failure": true},
+    {"script": "version.sh", "outputs": ["tag"]},
+    {"script": "tag.sh", "args": ["{{tag}}"], "env": {"GOFLAGS": "-mod=mod"}, "input": "yes\n"},
+    {"script": "rollback.sh", "when": "failure"},
+    {"script": "cleanup.sh", "when": "always"}
+  ]
+}`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	chain, err := NewScriptRunner(tempDir).LoadChain(workflowPath)
+	if err != nil {
+		t.Fatalf("Failed to load workflow: %v", err)
+	}
+	if chain.name != "release" {
+		t.Errorf("Expected chain name from the file name, got %q", chain.name)
+	}
+
+	exitCode, output, err := chain.Execute()
+	if exitCode != 0 || err != nil {
+		t.Fatalf("Expected workflow to succeed, got %d %v\n%s", exitCode, err, output)
+	}
+	for _, want := range []string{"check test", "check vet", "lint", "tag v2.0.0 -mod=mod yes", "cleanup"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, output)
+		}
+	}
+	results := chain.Results()
+	if len(results) != 7 || !results[5].Skipped() {
+		t.Errorf("Expected 7 results with rollback skipped, got %d", len(results))
+	}
+}
+
+func TestLoadChainValidation(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeScripts(t, tempDir, map[string]string{
+		"check.sh": "#!/bin/bash\necho \"check\"\n",
+	})
+
+	runner := NewScriptRunner(tempDir)
+
+	tests := []struct {
+		name     string
+		workflow string
+		want     string
+	}{
+		{"missing script", `{"steps": [{"script": "check.sh"}, {"script": "missing.sh"}]}`, "missing.sh"},
+		{"unsupported script", `{"steps": [{"script": "notes.txt"}]}`, "unsupported script type"},
+		{"unknown when", `{"steps": [{"script": "check.sh", "when": "sometimes"}]}`, "unknown when"},
+		{"script and parallel", `{"steps": [{"script": "check.sh", "parallel": [{"script": "check.sh"}]}]}`, "either script or parallel"},
+		{"unknown field", `{"steps": [{"script": "check.sh", "allowFailure": true}]}`, "unknown field"},
+		{"missing output", `{"steps": [{"script": "check.sh", "args": ["{{tag}}"]}]}`, "{{tag}}"},
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write notes.txt: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "workflow.json")
+			if err := os.WriteFile(path, []byte(tt.workflow), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+
+			chain, err := runner.LoadChain(path)
+			if err == nil || chain != nil {
+				t.Fatalf("Expected validation error, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error to contain %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Output errors can be matched with errors.Is
+	_, err := runner.ChainFromWorkflow(Workflow{Steps: []WorkflowStep{{WorkflowCommand: WorkflowCommand{Script: "check.sh", Args: []string{"{{tag}}"}}}}})
+	if !errors.Is(err, ErrMissingOutput) {
+		t.Errorf("Expected ErrMissingOutput, got %v", err)
+	}
+}